@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/brochadoluis/temperature-exercise/internal/api"
+	"github.com/brochadoluis/temperature-exercise/internal/config"
+	"github.com/brochadoluis/temperature-exercise/internal/database"
+	"github.com/brochadoluis/temperature-exercise/internal/faults"
+	"github.com/brochadoluis/temperature-exercise/internal/requestid"
+	"github.com/brochadoluis/temperature-exercise/internal/scrapper"
+	"github.com/brochadoluis/temperature-exercise/internal/servicetoken"
+	"github.com/brochadoluis/temperature-exercise/internal/shutdown"
+	"github.com/brochadoluis/temperature-exercise/internal/tracing"
+)
+
+// bufferSize is the size of the in-memory connections between the services.
+const bufferSize = 1 << 20
+
+// allinone runs the API, scrapper and database service in one process, for
+// local development without docker-compose. The services talk gRPC over
+// in-memory listeners, and the database service keeps its documents in
+// memory instead of MongoDB.
+func main() {
+	log := logrus.New()
+	log.SetFormatter(&logrus.TextFormatter{})
+	log.AddHook(requestid.Hook{})
+	logrus.AddHook(requestid.Hook{})
+
+	cfg, err := config.Load("allinone", os.Args[1:])
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+	if cfg.PrintConfig {
+		if err := cfg.Print(os.Stdout); err != nil {
+			log.Fatalf("Failed to print configuration: %v", err)
+		}
+		return
+	}
+
+	ctx, stop := shutdown.Context(context.Background())
+	defer stop()
+
+	flushTraces, err := tracing.Setup(ctx, "allinone", cfg.Tracing)
+	if err != nil {
+		log.Fatalf("Failed to set up tracing: %v", err)
+	}
+	defer func() {
+		flushCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+		defer cancel()
+		if err := flushTraces(flushCtx); err != nil {
+			log.Errorf("Failed to flush traces: %v", err)
+		}
+	}()
+
+	serveErr := make(chan error, 3)
+
+	// Database service. The in-memory store has nothing to check, the
+	// service serves as long as the process runs
+	databaseListener := bufconn.Listen(bufferSize)
+	databaseServer := database.NewGRPCServer(cfg, database.NewMemoryStore(), nil)
+	go databaseServer.Run(ctx)
+	go func() {
+		serveErr <- errors.Wrap(databaseServer.Serve(databaseListener), "database service")
+	}()
+
+	// Scrapper service
+	scrapperFaults := faults.NewInjector(cfg.Faults.Enabled, faults.ScrapperTargets...)
+	scrapperDatabaseConn, err := dial(databaseListener, scrapper.DialOptions(cfg, scrapperFaults)...)
+	if err != nil {
+		log.Fatalf("Failed to connect the scrapper to the database service: %v", err)
+	}
+	defer scrapperDatabaseConn.Close()
+
+	scrapperListener := bufconn.Listen(bufferSize)
+	scrapperServer, err := scrapper.NewGRPCServer(cfg, scrapperDatabaseConn, scrapperFaults, http.DefaultTransport)
+	if err != nil {
+		log.Fatalf("Failed to set up the scrapper: %v", err)
+	}
+	go scrapperServer.Run(ctx)
+	go func() {
+		serveErr <- errors.Wrap(scrapperServer.Serve(scrapperListener), "scrapper")
+	}()
+
+	// API
+	apiSigner := servicetoken.NewSigner(servicetoken.API, cfg.ServiceAuth)
+	scrapperConn, err := dial(scrapperListener, api.DialOptions(cfg, apiSigner, servicetoken.Scrapper)...)
+	if err != nil {
+		log.Fatalf("Failed to connect to the Scrapper service: %v", err)
+	}
+	defer scrapperConn.Close()
+
+	databaseConn, err := dial(databaseListener, api.DialOptions(cfg, apiSigner, servicetoken.Database)...)
+	if err != nil {
+		log.Fatalf("Failed to connect to the Database service: %v", err)
+	}
+	defer databaseConn.Close()
+
+	router, alertStream, err := api.NewRouter(cfg, scrapperConn, databaseConn, log)
+	if err != nil {
+		log.Fatalf("Failed to set up the HTTP API: %v", err)
+	}
+
+	server := &http.Server{
+		Addr:    cfg.API.Addr,
+		Handler: router,
+	}
+	server.RegisterOnShutdown(alertStream.Shutdown)
+	go func() {
+		serveErr <- errors.Wrap(server.ListenAndServe(), "HTTP API")
+	}()
+
+	log.Infof("Serving the API on %s, with the scrapper and database service in process", cfg.API.Addr)
+
+	select {
+	case err := <-serveErr:
+		log.Fatalf("Failed to serve: %v", err)
+	case <-ctx.Done():
+		log.Info("Shutting down")
+	}
+
+	// Drain from the edge inwards, so in-flight requests can still reach the
+	// services behind them
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Errorf("Failed to drain the HTTP server: %v", err)
+	}
+	scrapperServer.Health.Shutdown()
+	if !shutdown.GracefulStop(scrapperServer.Server, cfg.ShutdownTimeout) {
+		log.Warn("Shutdown timeout reached, cancelled remaining scrapper calls")
+	}
+	databaseServer.Health.Shutdown()
+	if !shutdown.GracefulStop(databaseServer.Server, cfg.ShutdownTimeout) {
+		log.Warn("Shutdown timeout reached, cancelled remaining database calls")
+	}
+	log.Info("Stopped")
+}
+
+// dial connects to a server listening on the in-memory listener.
+func dial(listener *bufconn.Listener, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	return grpc.Dial("bufconn",
+		append(opts,
+			grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+				return listener.DialContext(ctx)
+			}),
+			grpc.WithTransportCredentials(insecure.NewCredentials()),
+		)...,
+	)
+}
@@ -1,55 +1,109 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
+	"os"
 
 	"github.com/brochadoluis/temperature-exercise/internal/api"
-	"github.com/brochadoluis/temperature-exercise/proto"
+	"github.com/brochadoluis/temperature-exercise/internal/config"
+	"github.com/brochadoluis/temperature-exercise/internal/requestid"
+	"github.com/brochadoluis/temperature-exercise/internal/servicetoken"
+	"github.com/brochadoluis/temperature-exercise/internal/shutdown"
+	"github.com/brochadoluis/temperature-exercise/internal/tlsconfig"
+	"github.com/brochadoluis/temperature-exercise/internal/tracing"
 
-	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 	"google.golang.org/grpc"
 )
 
 func main() {
-	scrapperConn, err := grpc.Dial("scrapper:50051", grpc.WithInsecure())
+	cfg, err := config.Load("api", os.Args[1:])
 	if err != nil {
-		log.Fatalf("Failed to connect to the Scrapper service: %v", err)
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+	if cfg.PrintConfig {
+		if err := cfg.Print(os.Stdout); err != nil {
+			log.Fatalf("Failed to print configuration: %v", err)
+		}
+		return
 	}
-	defer scrapperConn.Close()
-
-	apiService := api.NewAPIService(proto.NewTemperatureClient(scrapperConn))
-
-	router := gin.Default()
 
 	logger := logrus.New()
 	logger.SetFormatter(&logrus.JSONFormatter{})
-	router.Use(gin.LoggerWithWriter(logger.Writer()))
+	logger.AddHook(requestid.Hook{})
+	logrus.AddHook(requestid.Hook{})
 
-	router.GET("/getTemperature", func(c *gin.Context) {
-		latitude := c.Query("latitude")
-		longitude := c.Query("longitude")
-
-		resp, err := apiService.GetTemperature(latitude, longitude)
-		if err != nil {
-			logger.Errorf("Failed to get temperature: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get temperature"})
-			return
+	flushTraces, err := tracing.Setup(context.Background(), "api", cfg.Tracing)
+	if err != nil {
+		log.Fatalf("Failed to set up tracing: %v", err)
+	}
+	defer func() {
+		flushCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+		defer cancel()
+		if err := flushTraces(flushCtx); err != nil {
+			logger.Errorf("Failed to flush traces: %v", err)
 		}
+	}()
 
-		c.JSON(http.StatusOK, gin.H{
-			"Latitude":    resp.Latitude,
-			"Longitude":   resp.Longitude,
-			"Temperature": resp.Temperature,
-			"Alert":       resp.Alert,
-			"Error":       resp.Error,
-		})
-	})
+	signer := servicetoken.NewSigner(servicetoken.API, cfg.ServiceAuth)
 
-	// Start the HTTP server
-	err = router.Run(":8080")
+	scrapperCreds, err := tlsconfig.DialOption(cfg.TLS, cfg.TLS.ScrapperServerName)
+	if err != nil {
+		log.Fatalf("Failed to set up TLS: %v", err)
+	}
+	databaseCreds, err := tlsconfig.DialOption(cfg.TLS, cfg.TLS.DatabaseServerName)
+	if err != nil {
+		log.Fatalf("Failed to set up TLS: %v", err)
+	}
+
+	scrapperConn, err := grpc.Dial(cfg.API.ScrapperAddr, append(api.DialOptions(cfg, signer, servicetoken.Scrapper), scrapperCreds)...)
+	if err != nil {
+		log.Fatalf("Failed to connect to the Scrapper service: %v", err)
+	}
+	defer scrapperConn.Close()
+
+	databaseConn, err := grpc.Dial(cfg.API.DatabaseAddr, append(api.DialOptions(cfg, signer, servicetoken.Database), databaseCreds)...)
 	if err != nil {
+		log.Fatalf("Failed to connect to the Database service: %v", err)
+	}
+	defer databaseConn.Close()
+
+	router, alertStream, err := api.NewRouter(cfg, scrapperConn, databaseConn, logger)
+	if err != nil {
+		log.Fatalf("Failed to set up the HTTP API: %v", err)
+	}
+
+	server := &http.Server{
+		Addr:    cfg.API.Addr,
+		Handler: router,
+	}
+	// WebSocket connections are hijacked and not tracked by Shutdown, close
+	// them explicitly
+	server.RegisterOnShutdown(alertStream.Shutdown)
+
+	ctx, stop := shutdown.Context(context.Background())
+	defer stop()
+
+	// Start the HTTP server
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serveErr:
 		log.Fatalf("Failed to start the HTTP server: %v", err)
+	case <-ctx.Done():
+		logger.Info("Shutting down the HTTP server")
+	}
+
+	// Stop accepting requests and drain the in-flight ones
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		logger.Errorf("Failed to drain the HTTP server: %v", err)
 	}
+	logger.Info("HTTP server stopped")
 }
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"flag"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// services maps every service to the names it is reached by in
+// docker-compose. The certificates are used both as server and client
+// certificates.
+var services = map[string][]string{
+	"api":      {"api"},
+	"scrapper": {"scrapper", "scrapper-service"},
+	"database": {"server", "db-service"},
+}
+
+// certgen generates a local CA and a certificate for every service, for
+// testing the tls and mtls modes on a development machine. It is not meant
+// for production certificates.
+func main() {
+	out := flag.String("out", "certs", "directory the certificates are written to")
+	hosts := flag.String("hosts", "localhost,127.0.0.1", "comma separated names and IPs added to every certificate")
+	validity := flag.Duration("validity", 365*24*time.Hour, "validity of the certificates")
+	flag.Parse()
+
+	if err := generate(*out, strings.Split(*hosts, ","), *validity); err != nil {
+		fmt.Fprintf(os.Stderr, "certgen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func generate(out string, hosts []string, validity time.Duration) error {
+	if err := os.MkdirAll(out, 0o755); err != nil {
+		return errors.Wrap(err, "failed to create output directory")
+	}
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return errors.Wrap(err, "failed to generate CA key")
+	}
+	caTemplate, err := template("temperature-exercise local CA", validity)
+	if err != nil {
+		return err
+	}
+	caTemplate.IsCA = true
+	caTemplate.BasicConstraintsValid = true
+	caTemplate.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign
+
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		return errors.Wrap(err, "failed to create CA certificate")
+	}
+	if err := write(out, "ca", caDER, caKey); err != nil {
+		return err
+	}
+	caCert, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse CA certificate")
+	}
+
+	for service, names := range services {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			return errors.Wrapf(err, "failed to generate %s key", service)
+		}
+		tmpl, err := template(service, validity)
+		if err != nil {
+			return err
+		}
+		tmpl.KeyUsage = x509.KeyUsageDigitalSignature
+		tmpl.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth}
+		for _, host := range append(names, hosts...) {
+			if ip := net.ParseIP(host); ip != nil {
+				tmpl.IPAddresses = append(tmpl.IPAddresses, ip)
+			} else if host != "" {
+				tmpl.DNSNames = append(tmpl.DNSNames, host)
+			}
+		}
+
+		der, err := x509.CreateCertificate(rand.Reader, tmpl, caCert, &key.PublicKey, caKey)
+		if err != nil {
+			return errors.Wrapf(err, "failed to create %s certificate", service)
+		}
+		if err := write(out, service, der, key); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("Certificates written to %s\n", out)
+	return nil
+}
+
+func template(commonName string, validity time.Duration) (*x509.Certificate, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to generate serial number")
+	}
+
+	now := time.Now()
+	return &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(validity),
+	}, nil
+}
+
+// write saves the certificate as <name>.pem and its key as <name>-key.pem.
+func write(dir, name string, der []byte, key *ecdsa.PrivateKey) error {
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	if err := os.WriteFile(filepath.Join(dir, name+".pem"), certPEM, 0o644); err != nil {
+		return errors.Wrapf(err, "failed to write %s certificate", name)
+	}
+
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return errors.Wrapf(err, "failed to encode %s key", name)
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+	if err := os.WriteFile(filepath.Join(dir, name+"-key.pem"), keyPEM, 0o600); err != nil {
+		return errors.Wrapf(err, "failed to write %s key", name)
+	}
+	return nil
+}
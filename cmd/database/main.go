@@ -4,89 +4,186 @@ import (
 	"context"
 	"fmt"
 	"net"
-	"time"
+	"os"
 
+	"github.com/brochadoluis/temperature-exercise/internal/config"
 	"github.com/brochadoluis/temperature-exercise/internal/database"
-	"github.com/brochadoluis/temperature-exercise/proto"
-
+	"github.com/brochadoluis/temperature-exercise/internal/health"
+	"github.com/brochadoluis/temperature-exercise/internal/metrics"
+	"github.com/brochadoluis/temperature-exercise/internal/requestid"
+	"github.com/brochadoluis/temperature-exercise/internal/shutdown"
+	"github.com/brochadoluis/temperature-exercise/internal/tlsconfig"
+	"github.com/brochadoluis/temperature-exercise/internal/tracing"
+
+	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
-	"google.golang.org/grpc"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.opentelemetry.io/contrib/instrumentation/go.mongodb.org/mongo-driver/mongo/otelmongo"
 )
 
 func main() {
 	// Create a logger with logrus
 	logger := logrus.New()
 	logger.SetFormatter(&logrus.JSONFormatter{})
+	logger.AddHook(requestid.Hook{})
+	logrus.AddHook(requestid.Hook{})
 
-	// Create a context with the logger
-	ctx := context.WithValue(context.Background(), "logger", logger)
+	// Load the configuration
+	cfg, err := config.Load("database", os.Args[1:])
+	if err != nil {
+		logger.Fatalf("Failed to load configuration: %v", err)
+	}
+	if cfg.PrintConfig {
+		if err := cfg.Print(os.Stdout); err != nil {
+			logger.Fatalf("Failed to print configuration: %v", err)
+		}
+		return
+	}
 
-	connectToDB(ctx)
+	// Create a context cancelled on SIGINT or SIGTERM
+	ctx, stop := shutdown.Context(context.Background())
+	defer stop()
+
+	connectToDB(ctx, logger, cfg)
 
 }
 
-func connectToDB(ctx context.Context) {
+func connectToDB(ctx context.Context, logger *logrus.Logger, cfg *config.Config) {
 	// MongoDB connection string
-	connectionString := "mongodb://database:27017"
-	// Retrieve the logger from the context
-	logger := ctx.Value("logger").(*logrus.Logger)
+	connectionString := cfg.Database.MongoURI
 
-	// Create a MongoDB client
-	client, err := mongo.NewClient(options.Client().ApplyURI(connectionString))
+	// Export traces
+	flushTraces, err := tracing.Setup(ctx, "database", cfg.Tracing)
+	if err != nil {
+		logger.Fatalf("Failed to set up tracing: %v", err)
+	}
+	defer func() {
+		flushCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+		defer cancel()
+		if err := flushTraces(flushCtx); err != nil {
+			logger.Errorf("Failed to flush traces: %v", err)
+		}
+	}()
+
+	// Create a MongoDB client, traced so inserts show up under the RPC span
+	client, err := mongo.NewClient(options.Client().ApplyURI(connectionString).SetMonitor(otelmongo.NewMonitor()))
 	if err != nil {
 		logger.Fatalf("Failed to create MongoDB client: %v", err)
 	}
 
 	// Connect to the MongoDB server
-	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	connectCtx, cancel := context.WithTimeout(ctx, cfg.Database.ConnectTimeout)
 	defer cancel()
-	err = client.Connect(ctx)
+	err = client.Connect(connectCtx)
 	if err != nil {
 		logger.Fatalf("Failed to connect to MongoDB server: %v", err)
 	}
 	defer func() {
-		// Disconnect from the MongoDB server
-		if err := client.Disconnect(ctx); err != nil {
-			logger.Fatalf("Failed to disconnect from MongoDB: %v", err)
+		// Disconnect from the MongoDB server with a fresh context, the
+		// connect one has expired by the time the server shuts down
+		disconnectCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+		defer cancel()
+		if err := client.Disconnect(disconnectCtx); err != nil {
+			logger.Errorf("Failed to disconnect from MongoDB: %v", err)
+			return
 		}
+		logger.Info("Disconnected from MongoDB")
 	}()
 
 	// Access the database
-	db := client.Database("temperatures")
+	db := client.Database(cfg.Database.Name)
 
 	// Create collections if they don't exist
-	collections := []string{"success", "alert", "error"}
+	collections := []string{"success", "alert", "error", "forecast", database.APIKeysCollection, database.WatchlistCollection}
 	for _, collection := range collections {
-		err := createCollection(ctx, db, collection, logger)
+		err := createCollection(connectCtx, db, collection, logger)
 		if err != nil {
 			logger.Fatalf("Failed to create collection %s: %v", collection, err)
 		}
 	}
 
-	fmt.Println("Collections created successfully!")
+	// API keys are looked up by hash on every authenticated request
+	_, err = db.Collection(database.APIKeysCollection).Indexes().CreateOne(connectCtx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "hash", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		logger.Fatalf("Failed to create API key index: %v", err)
+	}
+
+	// Watchlist entries are named uniquely
+	_, err = db.Collection(database.WatchlistCollection).Indexes().CreateOne(connectCtx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "name", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		logger.Fatalf("Failed to create watchlist index: %v", err)
+	}
 
-	// Create a new instance of the database service
-	dbService := database.NewService(db)
+	// History is listed newest first
+	for _, collection := range []string{"success", "error"} {
+		_, err = db.Collection(collection).Indexes().CreateOne(connectCtx, mongo.IndexModel{
+			Keys: bson.D{{Key: "timestamp", Value: -1}},
+		})
+		if err != nil {
+			logger.Fatalf("Failed to create timestamp index on %s: %v", collection, err)
+		}
+	}
 
-	// Create a gRPC server
-	grpcServer := grpc.NewServer()
+	fmt.Println("Collections created successfully!")
 
-	// Register the database service with the gRPC server
-	proto.RegisterTemperatureServer(grpcServer, dbService)
+	creds, err := tlsconfig.ServerOption(cfg.TLS)
+	if err != nil {
+		logger.Fatalf("Failed to set up TLS: %v", err)
+	}
+
+	// Report the service as serving only while MongoDB answers pings
+	grpcServer := database.NewGRPCServer(cfg, database.NewMongoStore(db), map[string]health.Check{
+		"mongo": func(ctx context.Context) error {
+			return client.Ping(ctx, readpref.Primary())
+		},
+	}, creds)
+	go grpcServer.Run(ctx)
 
 	// Start the gRPC server
-	listener, err := net.Listen("tcp", ":50053")
+	listener, err := net.Listen("tcp", cfg.Database.Addr)
 	if err != nil {
 		logger.Fatalf("Failed to start gRPC server: %v", err)
 	}
 	defer listener.Close()
 
+	// Expose the Prometheus metrics
+	serveErr := make(chan error, 2)
+	metricsServer := metrics.Serve(cfg.Database.MetricsAddr, serveErr)
+
 	logger.Println("Starting gRPC server...")
-	err = grpcServer.Serve(listener)
-	if err != nil {
-		logger.Fatalf("gRPC server stopped: %v", err)
+	go func() {
+		serveErr <- errors.Wrap(grpcServer.Serve(listener), "gRPC server")
+	}()
+
+	// The metrics and gRPC servers report on the same channel, their errors
+	// name the server that stopped
+	select {
+	case err := <-serveErr:
+		logger.Fatalf("Failed to serve: %v", err)
+	case <-ctx.Done():
+		logger.Info("Shutting down gRPC server...")
+	}
+
+	// Let in-flight saves reach MongoDB before disconnecting
+	grpcServer.Health.Shutdown()
+	if !shutdown.GracefulStop(grpcServer.Server, cfg.ShutdownTimeout) {
+		logger.Warn("Shutdown timeout reached, cancelled remaining calls")
+	}
+	logger.Info("gRPC server stopped")
+
+	metricsCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	if err := metricsServer.Shutdown(metricsCtx); err != nil {
+		logger.Errorf("Failed to stop the metrics server: %v", err)
 	}
 }
 
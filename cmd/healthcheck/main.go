@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"google.golang.org/grpc"
+
+	"github.com/brochadoluis/temperature-exercise/internal/config"
+	"github.com/brochadoluis/temperature-exercise/internal/health"
+	"github.com/brochadoluis/temperature-exercise/internal/tlsconfig"
+)
+
+// healthcheck is a small probe meant for container healthchecks. It exits with
+// status 0 when the target is healthy and 1 otherwise.
+func main() {
+	grpcAddr := flag.String("grpc", "", "address of a gRPC server to check through grpc.health.v1")
+	httpURL := flag.String("http", "", "URL that must answer 200 OK")
+	timeout := flag.Duration("timeout", 3*time.Second, "time allowed for the check")
+
+	// The TLS settings default to the environment of the checked service, so
+	// container healthchecks work in every mode without extra flags
+	var tlsCfg config.TLS
+	flag.StringVar(&tlsCfg.Mode, "tls-mode", envOr("TLS_MODE", tlsconfig.ModeNone), "gRPC transport security: none, tls or mtls")
+	flag.StringVar(&tlsCfg.CAFile, "tls-ca-file", envOr("TLS_CA_FILE", ""), "CA bundle the server is verified against")
+	flag.StringVar(&tlsCfg.CertFile, "tls-cert-file", envOr("TLS_CERT_FILE", ""), "client certificate, for mtls")
+	flag.StringVar(&tlsCfg.KeyFile, "tls-key-file", envOr("TLS_KEY_FILE", ""), "client private key, for mtls")
+	serverName := flag.String("tls-server-name", "", "name expected in the server certificate, the host of -grpc by default")
+	flag.Parse()
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	var err error
+	switch {
+	case *grpcAddr != "":
+		err = checkGRPC(ctx, *grpcAddr, tlsCfg, *serverName)
+	case *httpURL != "":
+		err = checkHTTP(ctx, *httpURL)
+	default:
+		err = fmt.Errorf("one of -grpc or -http is required")
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "unhealthy: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func envOr(name, fallback string) string {
+	if value, ok := os.LookupEnv(config.EnvPrefix + name); ok {
+		return value
+	}
+	return fallback
+}
+
+func checkGRPC(ctx context.Context, addr string, tlsCfg config.TLS, serverName string) error {
+	creds, err := tlsconfig.DialOption(tlsCfg, serverName)
+	if err != nil {
+		return err
+	}
+
+	conn, err := grpc.DialContext(ctx, addr, creds)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	return health.GRPC(conn)(ctx)
+}
+
+func checkHTTP(ctx context.Context, url string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return nil
+}
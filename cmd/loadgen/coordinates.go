@@ -0,0 +1,76 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+)
+
+const (
+	distributionFixed    = "fixed"
+	distributionUniform  = "uniform"
+	distributionHotspots = "hotspots"
+)
+
+// hotspots are the locations of the hotspots distribution, most requested
+// first.
+var hotspots = [][2]float64{
+	{38.72, -9.14},   // Lisbon
+	{41.15, -8.61},   // Porto
+	{51.51, -0.13},   // London
+	{40.42, -3.70},   // Madrid
+	{48.86, 2.35},    // Paris
+	{52.52, 13.40},   // Berlin
+	{40.71, -74.01},  // New York
+	{35.68, 139.69},  // Tokyo
+	{-33.87, 151.21}, // Sydney
+	{-23.55, -46.63}, // São Paulo
+}
+
+// newCoordinates returns a function drawing the location of each request.
+//
+//   - fixed always requests latitude/longitude.
+//   - uniform draws from the square of side 2*radius centered on
+//     latitude/longitude, clamped to valid coordinates.
+//   - hotspots picks a city from the list above, the n-th one with a weight
+//     of 1/n, like traffic where a few locations are most of the requests.
+func newCoordinates(o *options, random *rand.Rand) func() (float64, float64) {
+	switch o.distribution {
+	case distributionUniform:
+		return func() (float64, float64) {
+			latitude := clamp(o.latitude+(random.Float64()*2-1)*o.radius, -90, 90)
+			longitude := clamp(o.longitude+(random.Float64()*2-1)*o.radius, -180, 180)
+			return round(latitude), round(longitude)
+		}
+	case distributionHotspots:
+		weights := make([]float64, len(hotspots))
+		var total float64
+		for i := range hotspots {
+			total += 1 / float64(i+1)
+			weights[i] = total
+		}
+		return func() (float64, float64) {
+			draw := random.Float64() * total
+			for i, weight := range weights {
+				if draw < weight {
+					return hotspots[i][0], hotspots[i][1]
+				}
+			}
+			last := hotspots[len(hotspots)-1]
+			return last[0], last[1]
+		}
+	default:
+		return func() (float64, float64) {
+			return o.latitude, o.longitude
+		}
+	}
+}
+
+func clamp(value, min, max float64) float64 {
+	return math.Max(min, math.Min(max, value))
+}
+
+// round keeps 4 decimals, about 10 meters, which is finer than the grid of
+// Open-Meteo and keeps the URLs short.
+func round(value float64) float64 {
+	return math.Round(value*1e4) / 1e4
+}
@@ -0,0 +1,277 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/brochadoluis/temperature-exercise/internal/config"
+	"github.com/brochadoluis/temperature-exercise/internal/requestid"
+	"github.com/brochadoluis/temperature-exercise/internal/shutdown"
+	"github.com/brochadoluis/temperature-exercise/internal/tlsconfig"
+)
+
+const (
+	formatText = "text"
+	formatJSON = "json"
+)
+
+// options holds the flags of a run.
+type options struct {
+	target       string
+	url          string
+	path         string
+	apiKey       string
+	scrapperAddr string
+	tls          config.TLS
+	serviceAuth  config.ServiceAuth
+	concurrency  int
+	rate         float64
+	duration     time.Duration
+	timeout      time.Duration
+	distribution string
+	latitude     float64
+	longitude    float64
+	radius       float64
+	seed         int64
+	output       string
+	stub         string
+	stubLatency  time.Duration
+}
+
+// loadgen sends requests to the API or the scrapper for a while and reports
+// their latencies and errors. It is meant to run against a stack whose
+// Open-Meteo is the stub it serves with -stub, so the numbers measure the
+// services and Open-Meteo is not flooded.
+func main() {
+	o := &options{}
+	flag.StringVar(&o.target, "target", targetHTTP, "what to load: http, grpc-temperature or grpc-forecast")
+	flag.StringVar(&o.url, "url", "http://localhost:8080", "API base URL, for the http target")
+	flag.StringVar(&o.path, "path", "/v1/getTemperature", "API route taking latitude and longitude, for the http target")
+	flag.StringVar(&o.apiKey, "api-key", "", "API key sent in X-API-Key, for the http target")
+	flag.StringVar(&o.scrapperAddr, "scrapper", envOr("API_SCRAPPER_ADDR", "localhost:50051"), "scrapper service address, for the grpc targets")
+	flag.IntVar(&o.concurrency, "concurrency", 10, "number of requests in flight at most")
+	flag.Float64Var(&o.rate, "rate", 0, "requests per second across all workers, 0 for as fast as they go")
+	flag.DurationVar(&o.duration, "duration", 30*time.Second, "how long to send requests for")
+	flag.DurationVar(&o.timeout, "timeout", 10*time.Second, "deadline of a request")
+	flag.StringVar(&o.distribution, "distribution", distributionFixed, "coordinates requested: fixed, uniform or hotspots")
+	flag.Float64Var(&o.latitude, "latitude", 38.7, "latitude of the fixed distribution and center of the uniform one")
+	flag.Float64Var(&o.longitude, "longitude", -9.1, "longitude of the fixed distribution and center of the uniform one")
+	flag.Float64Var(&o.radius, "radius", 1, "half the side in degrees of the square the uniform distribution draws from")
+	flag.Int64Var(&o.seed, "seed", 1, "seed of the coordinates drawn, for repeatable runs")
+	flag.StringVar(&o.output, "output", formatText, "report format: text or json")
+	flag.StringVar(&o.stub, "stub", "", "serve a fake Open-Meteo on this address instead of sending requests")
+	flag.DurationVar(&o.stubLatency, "stub-latency", 0, "delay of the answers of the fake Open-Meteo")
+
+	// The TLS settings default to the environment of the services, like
+	// tempctl
+	flag.StringVar(&o.tls.Mode, "tls-mode", envOr("TLS_MODE", tlsconfig.ModeNone), "gRPC transport security: none, tls or mtls")
+	flag.StringVar(&o.tls.CAFile, "tls-ca-file", envOr("TLS_CA_FILE", ""), "CA bundle the scrapper is verified against")
+	flag.StringVar(&o.tls.CertFile, "tls-cert-file", envOr("TLS_CERT_FILE", ""), "client certificate, for mtls")
+	flag.StringVar(&o.tls.KeyFile, "tls-key-file", envOr("TLS_KEY_FILE", ""), "client private key, for mtls")
+	flag.StringVar(&o.tls.ScrapperServerName, "tls-scrapper-server-name", envOr("TLS_SCRAPPER_SERVER_NAME", ""), "name expected in the scrapper certificate")
+
+	// The scrapper only serves calls signed by a service, so the grpc
+	// targets sign theirs as loadgen, like tempctl, with a key bound to
+	// loadgen
+	var keys string
+	flag.BoolVar(&o.serviceAuth.Enabled, "service-auth", envOr("SERVICE_AUTH_ENABLED", "false") == "true", "sign the calls with a service token, for the grpc targets")
+	flag.StringVar(&o.serviceAuth.SigningKeyID, "service-auth-signing-key-id", envOr("SERVICE_AUTH_SIGNING_KEY_ID", ""), "ID of the key service tokens are signed with")
+	flag.StringVar(&keys, "service-auth-keys", envOr("SERVICE_AUTH_KEYS", ""), "service token keys as id=secret pairs separated by commas")
+	flag.Parse()
+
+	if err := o.validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "loadgen: %v\n", err)
+		os.Exit(2)
+	}
+	if o.serviceAuth.Enabled {
+		parsed, err := config.ParsePairs(keys)
+		if err != nil || parsed[o.serviceAuth.SigningKeyID] == "" {
+			fmt.Fprintf(os.Stderr, "loadgen: -service-auth requires the key of -service-auth-signing-key-id in -service-auth-keys\n")
+			os.Exit(2)
+		}
+		o.serviceAuth.Keys = parsed
+		o.serviceAuth.TokenTTL = time.Minute
+	}
+
+	ctx, stop := shutdown.Context(context.Background())
+	defer stop()
+
+	var err error
+	if o.stub != "" {
+		err = serveStub(ctx, o.stub, o.stubLatency)
+	} else {
+		err = run(ctx, o)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "loadgen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func (o *options) validate() error {
+	switch {
+	case o.target != targetHTTP && o.target != targetTemperature && o.target != targetForecast:
+		return errors.Errorf("unknown target %q", o.target)
+	case o.distribution != distributionFixed && o.distribution != distributionUniform && o.distribution != distributionHotspots:
+		return errors.Errorf("unknown distribution %q", o.distribution)
+	case o.output != formatText && o.output != formatJSON:
+		return errors.Errorf("unknown output format %q", o.output)
+	case o.concurrency < 1:
+		return errors.New("-concurrency must be at least 1")
+	case o.rate < 0:
+		return errors.New("-rate must not be negative")
+	case o.rate > 0 && interval(o.rate) <= 0:
+		return errors.New("-rate must be at most 1e9 per second")
+	case o.duration <= 0:
+		return errors.New("-duration must be positive")
+	case o.radius < 0:
+		return errors.New("-radius must not be negative")
+	}
+	return nil
+}
+
+// run sends requests until the duration is over or the process is
+// interrupted, then prints the report. Interrupted runs still report what
+// they sent.
+func run(ctx context.Context, o *options) error {
+	send, closeTarget, err := newTarget(o)
+	if err != nil {
+		return err
+	}
+	defer closeTarget()
+
+	ctx, cancel := context.WithTimeout(ctx, o.duration)
+	defer cancel()
+
+	// Without a rate every worker sends its next request as soon as the
+	// previous one is answered. With one, the workers take the scheduled
+	// time of every request from the dispatcher and measure latency from it,
+	// so requests delayed by slow answers count as slow instead of being
+	// left out of the report
+	start := time.Now()
+	var schedule chan time.Time
+	late := make(chan int, 1)
+	if o.rate > 0 {
+		schedule = make(chan time.Time)
+		go func() {
+			late <- dispatch(ctx, schedule, start, interval(o.rate))
+		}()
+	}
+
+	workers := make([]*results, o.concurrency)
+	var wg sync.WaitGroup
+	for i := range workers {
+		r := newResults()
+		workers[i] = r
+		// Each worker draws from its own source, so a seed gives the same
+		// coordinates whatever the scheduling
+		coordinates := newCoordinates(o, rand.New(rand.NewSource(o.seed+int64(i))))
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				began := time.Now()
+				if schedule != nil {
+					select {
+					case <-ctx.Done():
+						return
+					case began = <-schedule:
+					}
+				}
+				if ctx.Err() != nil {
+					return
+				}
+
+				// Requests in flight when the run ends are allowed to finish, so
+				// they are not counted as errors
+				latitude, longitude := coordinates()
+				callCtx, callCancel := context.WithTimeout(requestid.NewContext(context.Background(), requestid.New()), o.timeout)
+				label, ok := send(callCtx, latitude, longitude)
+				r.add(label, ok, time.Since(began))
+				callCancel()
+			}
+		}()
+	}
+	wg.Wait()
+
+	report := newReport(o, time.Since(start), workers)
+	if o.rate > 0 {
+		report.Behind = <-late
+	}
+	if o.output == formatJSON {
+		return report.writeJSON(os.Stdout)
+	}
+	return report.writeText(os.Stdout)
+}
+
+// interval is the time between the requests of a rate, 0 when the rate is
+// too high to be scheduled.
+func interval(rate float64) time.Duration {
+	return time.Duration(float64(time.Second) / rate)
+}
+
+// dispatch hands the scheduled time of every request to the workers, one
+// every interval from start, until ctx is done. Requests are not skipped when
+// every worker is busy: the dispatcher waits for one, then hands out the late
+// requests at once. It returns the number of requests that were due but not
+// handed out when the run ended.
+func dispatch(ctx context.Context, schedule chan<- time.Time, start time.Time, interval time.Duration) int {
+	deadline, hasDeadline := ctx.Deadline()
+	sent := 0
+	for {
+		// Requests due at the deadline are not sent, their timer can fire
+		// before ctx is done
+		scheduled := start.Add(time.Duration(sent) * interval)
+		if hasDeadline && !scheduled.Before(deadline) {
+			<-ctx.Done()
+			return behind(ctx, start, interval, sent)
+		}
+		if wait := time.Until(scheduled); wait > 0 {
+			timer := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return behind(ctx, start, interval, sent)
+			case <-timer.C:
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return behind(ctx, start, interval, sent)
+		case schedule <- scheduled:
+			sent++
+		}
+	}
+}
+
+// behind is the number of requests scheduled before the run ended, at its
+// deadline or when it was interrupted, that were not sent.
+func behind(ctx context.Context, start time.Time, interval time.Duration, sent int) int {
+	end := time.Now()
+	if deadline, ok := ctx.Deadline(); ok && deadline.Before(end) {
+		end = deadline
+	}
+	if !end.After(start) {
+		return 0
+	}
+	due := int((end.Sub(start)-1)/interval) + 1
+	if due < sent {
+		return 0
+	}
+	return due - sent
+}
+
+func envOr(name, fallback string) string {
+	if value, ok := os.LookupEnv(config.EnvPrefix + name); ok {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestValidateRate(t *testing.T) {
+	tests := []struct {
+		rate  float64
+		valid bool
+	}{
+		{0, true},
+		{0.5, true},
+		{1e9, true},
+		{-1, false},
+		{2e9, false},
+	}
+	for _, tt := range tests {
+		o := &options{
+			target:       targetHTTP,
+			distribution: distributionFixed,
+			output:       formatText,
+			concurrency:  1,
+			rate:         tt.rate,
+			duration:     time.Second,
+		}
+		if err := o.validate(); (err == nil) != tt.valid {
+			t.Errorf("Rate %v validated with %v, want valid %v", tt.rate, err, tt.valid)
+		}
+	}
+}
+
+func TestDispatch(t *testing.T) {
+	const interval = 10 * time.Millisecond
+	ctx, cancel := context.WithTimeout(context.Background(), 20*interval)
+	defer cancel()
+
+	// The worker is stuck on its first request for most of the run, so the
+	// requests due meanwhile are handed out late and at once
+	start := time.Now()
+	schedule := make(chan time.Time)
+	late := make(chan int, 1)
+	go func() {
+		late <- dispatch(ctx, schedule, start, interval)
+	}()
+
+	var scheduled []time.Time
+	for {
+		select {
+		case at := <-schedule:
+			scheduled = append(scheduled, at)
+			if len(scheduled) == 1 {
+				time.Sleep(10 * interval)
+			}
+			continue
+		case <-ctx.Done():
+		}
+		break
+	}
+
+	for i, at := range scheduled {
+		if want := start.Add(time.Duration(i) * interval); !at.Equal(want) {
+			t.Fatalf("Request %d is scheduled at %v, want %v", i, at.Sub(start), want.Sub(start))
+		}
+	}
+	if len(scheduled) < 15 {
+		t.Errorf("Dispatched %d requests, want the late ones caught up", len(scheduled))
+	}
+	if behind := <-late; behind < 0 || behind+len(scheduled) > 21 {
+		t.Errorf("Reported %d requests behind after dispatching %d of 20", behind, len(scheduled))
+	}
+}
+
+func TestDispatchBehind(t *testing.T) {
+	const interval = 10 * time.Millisecond
+	ctx, cancel := context.WithTimeout(context.Background(), 20*interval)
+	defer cancel()
+
+	// Nobody takes the requests, so every one due before the deadline is
+	// behind
+	if behind := dispatch(ctx, make(chan time.Time), time.Now(), interval); behind != 20 {
+		t.Errorf("Reported %d requests behind, want 20", behind)
+	}
+}
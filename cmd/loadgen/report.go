@@ -0,0 +1,155 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"text/tabwriter"
+	"time"
+)
+
+// results are the outcomes of the requests of one worker, so workers record
+// them without locking.
+type results struct {
+	latencies []time.Duration
+	outcomes  map[string]int
+	failures  int
+}
+
+func newResults() *results {
+	return &results{outcomes: make(map[string]int)}
+}
+
+func (r *results) add(label string, ok bool, latency time.Duration) {
+	r.latencies = append(r.latencies, latency)
+	r.outcomes[label]++
+	if !ok {
+		r.failures++
+	}
+}
+
+// Latencies are the latency percentiles of a run, in milliseconds.
+type Latencies struct {
+	P50 float64 `json:"p50_ms"`
+	P90 float64 `json:"p90_ms"`
+	P99 float64 `json:"p99_ms"`
+	Max float64 `json:"max_ms"`
+}
+
+// Report sums up a run. Outcomes counts the requests by HTTP status or gRPC
+// code, successful ones included. Behind counts the requests of a target
+// rate that were due but never sent, because the workers were all busy.
+type Report struct {
+	Target       string         `json:"target"`
+	Distribution string         `json:"distribution"`
+	Concurrency  int            `json:"concurrency"`
+	TargetRate   float64        `json:"target_rate,omitempty"`
+	Duration     float64        `json:"duration_seconds"`
+	Requests     int            `json:"requests"`
+	Failures     int            `json:"failures"`
+	Behind       int            `json:"behind"`
+	Rate         float64        `json:"rate"`
+	Latency      Latencies      `json:"latency"`
+	Outcomes     map[string]int `json:"outcomes"`
+}
+
+func newReport(o *options, elapsed time.Duration, workers []*results) *Report {
+	report := &Report{
+		Target:       o.target,
+		Distribution: o.distribution,
+		Concurrency:  o.concurrency,
+		TargetRate:   o.rate,
+		Duration:     math.Round(elapsed.Seconds()*1000) / 1000,
+		Outcomes:     make(map[string]int),
+	}
+
+	var latencies []time.Duration
+	for _, r := range workers {
+		latencies = append(latencies, r.latencies...)
+		report.Failures += r.failures
+		for label, n := range r.outcomes {
+			report.Outcomes[label] += n
+		}
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+
+	report.Requests = len(latencies)
+	if elapsed > 0 {
+		report.Rate = math.Round(float64(report.Requests)/elapsed.Seconds()*10) / 10
+	}
+	report.Latency = Latencies{
+		P50: milliseconds(percentile(latencies, 50)),
+		P90: milliseconds(percentile(latencies, 90)),
+		P99: milliseconds(percentile(latencies, 99)),
+		Max: milliseconds(percentile(latencies, 100)),
+	}
+	return report
+}
+
+// percentile returns the nearest-rank percentile of sorted latencies, 0 when
+// there are none.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+func milliseconds(d time.Duration) float64 {
+	return math.Round(float64(d)/float64(time.Millisecond)*100) / 100
+}
+
+func (r *Report) writeJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+func (r *Report) writeText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	rate := "unbounded"
+	if r.TargetRate > 0 {
+		rate = fmt.Sprintf("%g/s", r.TargetRate)
+	}
+	fmt.Fprintf(tw, "target\t%s, %s coordinates\n", r.Target, r.Distribution)
+	fmt.Fprintf(tw, "load\t%d workers, %s\n", r.Concurrency, rate)
+	fmt.Fprintf(tw, "duration\t%.1fs\n", r.Duration)
+	fmt.Fprintf(tw, "requests\t%d (%.1f/s)\n", r.Requests, r.Rate)
+	fmt.Fprintf(tw, "failures\t%d (%.1f%%)\n", r.Failures, r.failureShare()*100)
+	if r.TargetRate > 0 {
+		fmt.Fprintf(tw, "behind\t%d not sent\n", r.Behind)
+	}
+	fmt.Fprintf(tw, "latency\tp50 %.2fms  p90 %.2fms  p99 %.2fms  max %.2fms\n",
+		r.Latency.P50, r.Latency.P90, r.Latency.P99, r.Latency.Max)
+
+	// Most frequent outcomes first
+	labels := make([]string, 0, len(r.Outcomes))
+	for label := range r.Outcomes {
+		labels = append(labels, label)
+	}
+	sort.Slice(labels, func(i, j int) bool {
+		if r.Outcomes[labels[i]] != r.Outcomes[labels[j]] {
+			return r.Outcomes[labels[i]] > r.Outcomes[labels[j]]
+		}
+		return labels[i] < labels[j]
+	})
+	fmt.Fprintln(tw, "outcomes")
+	for _, label := range labels {
+		fmt.Fprintf(tw, "  %s\t%d\n", label, r.Outcomes[label])
+	}
+	return tw.Flush()
+}
+
+func (r *Report) failureShare() float64 {
+	if r.Requests == 0 {
+		return 0
+	}
+	return float64(r.Failures) / float64(r.Requests)
+}
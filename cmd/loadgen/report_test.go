@@ -0,0 +1,65 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestPercentile(t *testing.T) {
+	var sorted []time.Duration
+	for i := 1; i <= 100; i++ {
+		sorted = append(sorted, time.Duration(i)*time.Millisecond)
+	}
+
+	tests := []struct {
+		p    float64
+		want time.Duration
+	}{
+		{0, time.Millisecond},
+		{50, 50 * time.Millisecond},
+		{99, 99 * time.Millisecond},
+		{100, 100 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		if got := percentile(sorted, tt.p); got != tt.want {
+			t.Errorf("Percentile %v is %v, want %v", tt.p, got, tt.want)
+		}
+	}
+	if got := percentile(nil, 50); got != 0 {
+		t.Errorf("Percentile of no latencies is %v, want 0", got)
+	}
+}
+
+func TestReport(t *testing.T) {
+	a, b := newResults(), newResults()
+	a.add("200", true, 10*time.Millisecond)
+	a.add("429", false, time.Millisecond)
+	b.add("200", true, 20*time.Millisecond)
+	b.add("timeout", false, time.Second)
+
+	o := &options{target: targetHTTP, distribution: distributionFixed, concurrency: 2}
+	report := newReport(o, 2*time.Second, []*results{a, b})
+
+	if report.Requests != 4 || report.Failures != 2 || report.Rate != 2 {
+		t.Errorf("Report counts %d requests, %d failures at %v/s, want 4, 2 at 2/s", report.Requests, report.Failures, report.Rate)
+	}
+	if report.Outcomes["200"] != 2 || report.Outcomes["429"] != 1 || report.Outcomes["timeout"] != 1 {
+		t.Errorf("Outcomes are %v", report.Outcomes)
+	}
+	if report.Latency.P50 != 10 || report.Latency.Max != 1000 {
+		t.Errorf("Latencies are %+v, want p50 10ms and max 1000ms", report.Latency)
+	}
+}
+
+func TestUniformCoordinates(t *testing.T) {
+	o := &options{distribution: distributionUniform, latitude: 89.5, longitude: 179.5, radius: 2}
+	coordinates := newCoordinates(o, rand.New(rand.NewSource(1)))
+
+	for i := 0; i < 1000; i++ {
+		latitude, longitude := coordinates()
+		if latitude < 87.5 || latitude > 90 || longitude < 177.5 || longitude > 180 {
+			t.Fatalf("Drew %v,%v, outside the square clamped to valid coordinates", latitude, longitude)
+		}
+	}
+}
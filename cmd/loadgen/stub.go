@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// stubTemperature is the temperature of every answer of the stub, in Celsius.
+const stubTemperature = 18.5
+
+// serveStub serves a fake Open-Meteo forecast endpoint on addr until ctx is
+// cancelled. It answers any location at once, or after latency, with a fixed
+// current weather and constant hourly and daily values for the variables
+// requested.
+func serveStub(ctx context.Context, addr string, latency time.Duration) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return errors.Wrapf(err, "failed to listen on %s", addr)
+	}
+
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if latency > 0 {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(latency):
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(stubAnswer(r))
+	})}
+
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
+
+	log.Infof("Serving a fake Open-Meteo on http://%s/v1/forecast", listener.Addr())
+	if err := server.Serve(listener); err != http.ErrServerClosed {
+		return errors.Wrap(err, "failed to serve the fake Open-Meteo")
+	}
+	return nil
+}
+
+// stubAnswer builds the body of an answer to r, echoing the location like
+// Open-Meteo does.
+func stubAnswer(r *http.Request) map[string]interface{} {
+	query := r.URL.Query()
+	latitude, _ := strconv.ParseFloat(query.Get("latitude"), 64)
+	longitude, _ := strconv.ParseFloat(query.Get("longitude"), 64)
+	now := time.Now().UTC().Truncate(time.Hour)
+
+	answer := map[string]interface{}{
+		"latitude":  latitude,
+		"longitude": longitude,
+		"timezone":  "UTC",
+		"current_weather": map[string]interface{}{
+			"temperature":   stubTemperature,
+			"windspeed":     10.0,
+			"winddirection": 90.0,
+			"weathercode":   3,
+			"is_day":        1,
+			"time":          now.Format("2006-01-02T15:04"),
+		},
+	}
+
+	days, err := strconv.Atoi(query.Get("forecast_days"))
+	if err != nil || days < 1 {
+		days = 7
+	}
+	if hourly := query.Get("hourly"); hourly != "" {
+		answer["hourly"], answer["hourly_units"] = stubSeries(strings.Split(hourly, ","), now, time.Hour, days*24, "2006-01-02T15:04")
+	}
+	if daily := query.Get("daily"); daily != "" {
+		day := now.Truncate(24 * time.Hour)
+		answer["daily"], answer["daily_units"] = stubSeries(strings.Split(daily, ","), day, 24*time.Hour, days, "2006-01-02")
+	}
+	return answer
+}
+
+// stubSeries returns a block of n times from start with every variable set
+// to the stub temperature, and the units of the block.
+func stubSeries(variables []string, start time.Time, step time.Duration, n int, layout string) (map[string]interface{}, map[string]string) {
+	times := make([]string, n)
+	values := make([]float64, n)
+	for i := range times {
+		times[i] = start.Add(time.Duration(i) * step).Format(layout)
+		values[i] = stubTemperature
+	}
+
+	block := map[string]interface{}{"time": times}
+	units := map[string]string{"time": "iso8601"}
+	for _, variable := range variables {
+		block[variable] = values
+		units[variable] = "°C"
+	}
+	return block, units
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+
+	"github.com/brochadoluis/temperature-exercise/internal/requestid"
+	"github.com/brochadoluis/temperature-exercise/internal/servicetoken"
+	"github.com/brochadoluis/temperature-exercise/internal/tlsconfig"
+	"github.com/brochadoluis/temperature-exercise/proto"
+)
+
+const (
+	targetHTTP        = "http"
+	targetTemperature = "grpc-temperature"
+	targetForecast    = "grpc-forecast"
+)
+
+// sender sends one request for a location. It returns the label the outcome
+// is counted under in the report, the HTTP status or the gRPC code, and
+// whether the request succeeded.
+type sender func(ctx context.Context, latitude, longitude float64) (label string, ok bool)
+
+// newTarget returns the sender of the target and a function releasing its
+// connections.
+func newTarget(o *options) (sender, func(), error) {
+	if o.target == targetHTTP {
+		return newHTTPSender(o)
+	}
+
+	creds, err := tlsconfig.DialOption(o.tls, o.tls.ScrapperServerName)
+	if err != nil {
+		return nil, nil, err
+	}
+	signer := servicetoken.NewSigner(servicetoken.Loadgen, o.serviceAuth)
+	conn, err := grpc.Dial(o.scrapperAddr, creds,
+		grpc.WithChainUnaryInterceptor(requestid.UnaryClientInterceptor(), signer.UnaryClientInterceptor(servicetoken.Scrapper)),
+	)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to dial %s", o.scrapperAddr)
+	}
+	client := proto.NewTemperatureClient(conn)
+	closeConn := func() { conn.Close() }
+
+	if o.target == targetForecast {
+		return func(ctx context.Context, latitude, longitude float64) (string, bool) {
+			_, err := client.ListForecast(ctx, &proto.ListForecastRequest{Latitude: latitude, Longitude: longitude})
+			return grpcOutcome(err)
+		}, closeConn, nil
+	}
+	return func(ctx context.Context, latitude, longitude float64) (string, bool) {
+		_, err := client.ListTemperature(ctx, &proto.ListTemperatureRequest{Latitude: latitude, Longitude: longitude})
+		return grpcOutcome(err)
+	}, closeConn, nil
+}
+
+func newHTTPSender(o *options) (sender, func(), error) {
+	endpoint, err := url.Parse(o.url + o.path)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "invalid URL %q", o.url+o.path)
+	}
+
+	// Every worker keeps its connection open between requests
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConnsPerHost = o.concurrency
+	client := &http.Client{Transport: transport}
+
+	send := func(ctx context.Context, latitude, longitude float64) (string, bool) {
+		u := *endpoint
+		query := u.Query()
+		query.Set("latitude", strconv.FormatFloat(latitude, 'f', -1, 64))
+		query.Set("longitude", strconv.FormatFloat(longitude, 'f', -1, 64))
+		u.RawQuery = query.Encode()
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+		if err != nil {
+			return "invalid request", false
+		}
+		req.Header.Set(requestid.Header, requestid.FromContext(ctx))
+		if o.apiKey != "" {
+			req.Header.Set("X-API-Key", o.apiKey)
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			if ctx.Err() != nil {
+				return "timeout", false
+			}
+			return "connection error", false
+		}
+		// The body is drained so the connection is reused
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		return strconv.Itoa(resp.StatusCode), resp.StatusCode < 300
+	}
+	return send, transport.CloseIdleConnections, nil
+}
+
+func grpcOutcome(err error) (string, bool) {
+	code := status.Code(err)
+	return code.String(), err == nil
+}
@@ -0,0 +1,15 @@
+package main
+
+import (
+	"os"
+
+	"github.com/brochadoluis/temperature-exercise/internal/api"
+)
+
+// openapi prints the OpenAPI document of the HTTP API, the same one served
+// at /openapi.json. It is used by make openapi to generate the client.
+func main() {
+	if _, err := os.Stdout.Write(api.Spec()); err != nil {
+		os.Exit(1)
+	}
+}
@@ -1,48 +1,117 @@
 package main
 
 import (
+	"context"
 	"net"
+	"net/http"
+	"os"
 
+	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"google.golang.org/grpc"
 
+	"github.com/brochadoluis/temperature-exercise/internal/config"
+	"github.com/brochadoluis/temperature-exercise/internal/faults"
+	"github.com/brochadoluis/temperature-exercise/internal/metrics"
+	"github.com/brochadoluis/temperature-exercise/internal/requestid"
 	"github.com/brochadoluis/temperature-exercise/internal/scrapper"
-	"github.com/brochadoluis/temperature-exercise/proto"
+	"github.com/brochadoluis/temperature-exercise/internal/shutdown"
+	"github.com/brochadoluis/temperature-exercise/internal/tlsconfig"
+	"github.com/brochadoluis/temperature-exercise/internal/tracing"
 )
 
 func main() {
 	log := logrus.New()
 	log.SetFormatter(&logrus.TextFormatter{})
+	log.AddHook(requestid.Hook{})
+	logrus.AddHook(requestid.Hook{})
 
-	conn, err := grpc.Dial("server:50053", grpc.WithInsecure())
+	cfg, err := config.Load("scrapper", os.Args[1:])
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+	if cfg.PrintConfig {
+		if err := cfg.Print(os.Stdout); err != nil {
+			log.Fatalf("Failed to print configuration: %v", err)
+		}
+		return
+	}
+
+	flushTraces, err := tracing.Setup(context.Background(), "scrapper", cfg.Tracing)
+	if err != nil {
+		log.Fatalf("Failed to set up tracing: %v", err)
+	}
+	defer func() {
+		flushCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+		defer cancel()
+		if err := flushTraces(flushCtx); err != nil {
+			log.Errorf("Failed to flush traces: %v", err)
+		}
+	}()
+
+	dialCreds, err := tlsconfig.DialOption(cfg.TLS, cfg.TLS.DatabaseServerName)
+	if err != nil {
+		log.Fatalf("Failed to set up TLS: %v", err)
+	}
+
+	injector := faults.NewInjector(cfg.Faults.Enabled, faults.ScrapperTargets...)
+	conn, err := grpc.Dial(cfg.Scrapper.DatabaseAddr, append(scrapper.DialOptions(cfg, injector), dialCreds)...)
 	if err != nil {
 		log.Fatalf("Failed to dial server: %v", err)
 	}
 	defer conn.Close()
 
-	grpcClient := proto.NewTemperatureClient(conn)
-
-	scrapperClient := scrapper.NewClient(grpcClient)
+	ctx, stop := shutdown.Context(context.Background())
+	defer stop()
 
-	startGRPCServer(log, scrapperClient)
+	// The database connection is closed by the deferred Close once the server
+	// has drained, so in-flight saves can still complete
+	startGRPCServer(ctx, log, cfg, conn, injector)
 }
 
-func startGRPCServer(log *logrus.Logger, scrapperClient *scrapper.Client) {
-	server := grpc.NewServer()
-
-	serverImpl := &scrapper.Server{
-		Client: scrapperClient,
+func startGRPCServer(ctx context.Context, log *logrus.Logger, cfg *config.Config, databaseConn *grpc.ClientConn, injector *faults.Injector) {
+	creds, err := tlsconfig.ServerOption(cfg.TLS)
+	if err != nil {
+		log.Fatalf("Failed to set up TLS: %v", err)
 	}
 
-	proto.RegisterTemperatureServer(server, serverImpl)
+	server, err := scrapper.NewGRPCServer(cfg, databaseConn, injector, http.DefaultTransport, creds)
+	if err != nil {
+		log.Fatalf("Failed to set up the scrapper: %v", err)
+	}
+	go server.Run(ctx)
 
-	listener, err := net.Listen("tcp", ":50051")
+	listener, err := net.Listen("tcp", cfg.Scrapper.Addr)
 	if err != nil {
 		log.Fatalf("Failed to listen: %v", err)
 	}
 
+	serveErr := make(chan error, 2)
+	metricsServer := metrics.Serve(cfg.Scrapper.MetricsAddr, serveErr)
+
 	log.Info("Scrapper gRPC server started")
-	if err := server.Serve(listener); err != nil {
+	go func() {
+		serveErr <- errors.Wrap(server.Serve(listener), "gRPC server")
+	}()
+
+	// The metrics and gRPC servers report on the same channel, their errors
+	// name the server that stopped
+	select {
+	case err := <-serveErr:
 		log.Fatalf("Failed to serve: %v", err)
+	case <-ctx.Done():
+		log.Info("Shutting down the scrapper gRPC server")
+	}
+
+	server.Health.Shutdown()
+	if !shutdown.GracefulStop(server.Server, cfg.ShutdownTimeout) {
+		log.Warn("Shutdown timeout reached, cancelled remaining calls")
+	}
+	log.Info("Scrapper gRPC server stopped")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	if err := metricsServer.Shutdown(shutdownCtx); err != nil {
+		log.Errorf("Failed to stop the metrics server: %v", err)
 	}
 }
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/brochadoluis/temperature-exercise/proto"
+)
+
+var readingHeader = []string{"LATITUDE", "LONGITUDE", "TEMPERATURE", "CONDITION", "WIND_SPEED", "ALERT", "ERROR", "TIME"}
+
+// coordinates registers the location flags shared by the commands.
+func coordinates(fs *flag.FlagSet) (latitude, longitude *float64) {
+	latitude = fs.Float64("latitude", 0, "latitude in decimal degrees")
+	longitude = fs.Float64("longitude", 0, "longitude in decimal degrees")
+	return latitude, longitude
+}
+
+// requireFlags fails unless every named flag was set on the command line.
+func requireFlags(fs *flag.FlagSet, names ...string) error {
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	for _, name := range names {
+		if !set[name] {
+			return errors.Errorf("%s: -%s is required", fs.Name(), name)
+		}
+	}
+	return nil
+}
+
+func temperatureRow(t *proto.ListTemperatureResponse) []string {
+	return []string{
+		formatFloat(t.GetLatitude()),
+		formatFloat(t.GetLongitude()),
+		formatFloat(t.GetTemperature()),
+		t.GetCondition(),
+		formatFloat(t.GetWindSpeed()),
+		formatBool(t.GetAlert()),
+		formatBool(t.GetError()),
+		formatUnix(t.GetObservedAt()),
+	}
+}
+
+func runTemperature(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("temperature", flag.ContinueOnError)
+	latitude, longitude := coordinates(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireFlags(fs, "latitude", "longitude"); err != nil {
+		return err
+	}
+
+	client, err := e.scrapper()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, e.timeout)
+	defer cancel()
+
+	resp, err := client.ListTemperature(ctx, &proto.ListTemperatureRequest{Latitude: *latitude, Longitude: *longitude})
+	if err != nil {
+		return errors.Wrap(err, "failed to get temperature")
+	}
+
+	p := newPrinter(os.Stdout, e.output, readingHeader...)
+	if err := p.Row(resp, temperatureRow(resp)...); err != nil {
+		return err
+	}
+	return p.Flush()
+}
+
+func runHistory(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("history", flag.ContinueOnError)
+	latitude, longitude := coordinates(fs)
+	all := fs.Bool("all", false, "list the readings of every location")
+	since := fs.Duration("since", 24*time.Hour, "how far back to list readings, 0 for no bound")
+	limit := fs.Int("limit", 100, "maximum number of readings, newest first")
+	failed := fs.Bool("errors", false, "list the failed readings instead")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if !*all {
+		if err := requireFlags(fs, "latitude", "longitude"); err != nil {
+			return err
+		}
+	}
+
+	client, err := e.database()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, e.timeout)
+	defer cancel()
+
+	req := &proto.ListReadingsRequest{
+		Latitude:     *latitude,
+		Longitude:    *longitude,
+		AllLocations: *all,
+		Limit:        int32(*limit),
+		Errors:       *failed,
+	}
+	if *since > 0 {
+		req.Since = time.Now().Add(-*since).Unix()
+	}
+
+	resp, err := client.ListReadings(ctx, req)
+	if err != nil {
+		return errors.Wrap(err, "failed to list readings")
+	}
+
+	p := newPrinter(os.Stdout, e.output, append(readingHeader, "HTTP_CODE")...)
+	for _, r := range resp.GetReadings() {
+		err := p.Row(r,
+			formatFloat(r.GetLatitude()),
+			formatFloat(r.GetLongitude()),
+			formatFloat(r.GetTemperature()),
+			r.GetCondition(),
+			formatFloat(r.GetWindSpeed()),
+			formatBool(r.GetAlert()),
+			formatBool(r.GetError()),
+			formatUnix(r.GetTimestamp()),
+			strconv.Itoa(int(r.GetHttpCode())),
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return p.Flush()
+}
+
+func runAlerts(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("alerts", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	client, err := e.database()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, e.timeout)
+	defer cancel()
+
+	resp, err := client.ListActiveAlerts(ctx, &proto.ListActiveAlertsRequest{})
+	if err != nil {
+		return errors.Wrap(err, "failed to list alerts")
+	}
+
+	p := newPrinter(os.Stdout, e.output, "LATITUDE", "LONGITUDE", "TEMPERATURE", "SEVERITY", "SINCE")
+	for _, a := range resp.GetAlerts() {
+		err := p.Row(a,
+			formatFloat(a.GetLatitude()),
+			formatFloat(a.GetLongitude()),
+			formatFloat(a.GetTemperature()),
+			formatEnum(a.GetSeverity().String(), "ALERT_SEVERITY_"),
+			formatUnix(a.GetTimestamp()),
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return p.Flush()
+}
+
+func runWatch(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("watch", flag.ContinueOnError)
+	latitude, longitude := coordinates(fs)
+	interval := fs.Duration("interval", time.Minute, "time between readings, at least 1s")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireFlags(fs, "latitude", "longitude"); err != nil {
+		return err
+	}
+
+	client, err := e.scrapper()
+	if err != nil {
+		return err
+	}
+
+	stream, err := client.WatchTemperature(ctx, &proto.WatchTemperatureRequest{
+		Latitude:        *latitude,
+		Longitude:       *longitude,
+		IntervalSeconds: int32(interval.Seconds()),
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to watch temperature")
+	}
+
+	// Every reading is flushed as it arrives
+	p := newPrinter(os.Stdout, e.output, readingHeader...)
+	for {
+		resp, err := stream.Recv()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return errors.Wrap(err, "watch stopped")
+		}
+		if err := p.Row(resp, temperatureRow(resp)...); err != nil {
+			return err
+		}
+		if err := p.Flush(); err != nil {
+			return errors.Wrap(err, "failed to print reading")
+		}
+	}
+}
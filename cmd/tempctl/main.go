@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"google.golang.org/grpc"
+
+	"github.com/brochadoluis/temperature-exercise/internal/config"
+	"github.com/brochadoluis/temperature-exercise/internal/requestid"
+	"github.com/brochadoluis/temperature-exercise/internal/servicetoken"
+	"github.com/brochadoluis/temperature-exercise/internal/shutdown"
+	"github.com/brochadoluis/temperature-exercise/internal/tlsconfig"
+	"github.com/brochadoluis/temperature-exercise/proto"
+)
+
+// command is a subcommand of tempctl. run parses the arguments following the
+// command name.
+type command struct {
+	usage string
+	run   func(ctx context.Context, env *env, args []string) error
+}
+
+var commands = map[string]command{
+	"temperature": {"fetch the current temperature at a location", runTemperature},
+	"history":     {"list the stored readings of a location", runHistory},
+	"alerts":      {"list the open alerts", runAlerts},
+	"watch":       {"stream readings of a location until interrupted", runWatch},
+	"watchlist":   {"add, remove or list the watched locations", runWatchlist},
+}
+
+// env holds what the commands share: the connections to the services, the
+// output format and the timeout of a call.
+type env struct {
+	scrapperAddr string
+	databaseAddr string
+	tls          config.TLS
+	output       string
+	timeout      time.Duration
+	signer       *servicetoken.Signer
+	conns        []*grpc.ClientConn
+}
+
+// tempctl queries the scrapper and database services over gRPC, for
+// debugging without curling the API or opening a Mongo shell.
+func main() {
+	e := &env{}
+	flag.StringVar(&e.scrapperAddr, "scrapper", envOr("API_SCRAPPER_ADDR", "localhost:50051"), "scrapper service address")
+	flag.StringVar(&e.databaseAddr, "database", envOr("API_DATABASE_ADDR", "localhost:50053"), "database service address")
+	flag.StringVar(&e.output, "output", "table", "output format: table, json or csv")
+	flag.DurationVar(&e.timeout, "timeout", 10*time.Second, "deadline of a call, watch is not bounded")
+
+	// The TLS settings default to the environment of the services, like
+	// the healthcheck
+	flag.StringVar(&e.tls.Mode, "tls-mode", envOr("TLS_MODE", tlsconfig.ModeNone), "gRPC transport security: none, tls or mtls")
+	flag.StringVar(&e.tls.CAFile, "tls-ca-file", envOr("TLS_CA_FILE", ""), "CA bundle the services are verified against")
+	flag.StringVar(&e.tls.CertFile, "tls-cert-file", envOr("TLS_CERT_FILE", ""), "client certificate, for mtls")
+	flag.StringVar(&e.tls.KeyFile, "tls-key-file", envOr("TLS_KEY_FILE", ""), "client private key, for mtls")
+	flag.StringVar(&e.tls.ScrapperServerName, "tls-scrapper-server-name", envOr("TLS_SCRAPPER_SERVER_NAME", ""), "name expected in the scrapper certificate")
+	flag.StringVar(&e.tls.DatabaseServerName, "tls-database-server-name", envOr("TLS_DATABASE_SERVER_NAME", ""), "name expected in the database service certificate")
+
+	// Calls are signed as tempctl, as the database service only serves its
+	// history to tempctl. The key must be bound to tempctl in
+	// service_auth.key_issuers of the services, not one they sign with
+	var serviceAuth config.ServiceAuth
+	var keys string
+	flag.BoolVar(&serviceAuth.Enabled, "service-auth", envOr("SERVICE_AUTH_ENABLED", "false") == "true", "sign the calls with a service token")
+	flag.StringVar(&serviceAuth.SigningKeyID, "service-auth-signing-key-id", envOr("SERVICE_AUTH_SIGNING_KEY_ID", ""), "ID of the key service tokens are signed with")
+	flag.StringVar(&keys, "service-auth-keys", envOr("SERVICE_AUTH_KEYS", ""), "service token keys as id=secret pairs separated by commas")
+	flag.Usage = usage
+	flag.Parse()
+
+	cmd, ok := commands[flag.Arg(0)]
+	if !ok {
+		usage()
+		os.Exit(2)
+	}
+	if !validFormat(e.output) {
+		fmt.Fprintf(os.Stderr, "tempctl: unknown output format %q\n", e.output)
+		os.Exit(2)
+	}
+	if serviceAuth.Enabled {
+		parsed, err := config.ParsePairs(keys)
+		if err != nil || parsed[serviceAuth.SigningKeyID] == "" {
+			fmt.Fprintf(os.Stderr, "tempctl: -service-auth requires the key of -service-auth-signing-key-id in -service-auth-keys\n")
+			os.Exit(2)
+		}
+		serviceAuth.Keys = parsed
+		serviceAuth.TokenTTL = time.Minute
+	}
+	e.signer = servicetoken.NewSigner(servicetoken.Tempctl, serviceAuth)
+
+	ctx, stop := shutdown.Context(requestid.NewContext(context.Background(), requestid.New()))
+	defer stop()
+
+	err := cmd.run(ctx, e, flag.Args()[1:])
+	e.close()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "tempctl: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "Usage: tempctl [flags] <command> [command flags]\n\nCommands:\n")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(out, "  %-12s %s\n", name, commands[name].usage)
+	}
+	fmt.Fprintf(out, "\nFlags:\n")
+	flag.PrintDefaults()
+}
+
+func envOr(name, fallback string) string {
+	if value, ok := os.LookupEnv(config.EnvPrefix + name); ok {
+		return value
+	}
+	return fallback
+}
+
+func (e *env) dial(addr, serverName, audience string) (proto.TemperatureClient, error) {
+	creds, err := tlsconfig.DialOption(e.tls, serverName)
+	if err != nil {
+		return nil, err
+	}
+
+	// Request IDs make the calls of tempctl easy to find in the service logs
+	conn, err := grpc.Dial(addr, creds,
+		grpc.WithChainUnaryInterceptor(requestid.UnaryClientInterceptor(), e.signer.UnaryClientInterceptor(audience)),
+		grpc.WithChainStreamInterceptor(requestid.StreamClientInterceptor(), e.signer.StreamClientInterceptor(audience)),
+	)
+	if err != nil {
+		return nil, err
+	}
+	e.conns = append(e.conns, conn)
+	return proto.NewTemperatureClient(conn), nil
+}
+
+func (e *env) scrapper() (proto.TemperatureClient, error) {
+	return e.dial(e.scrapperAddr, e.tls.ScrapperServerName, servicetoken.Scrapper)
+}
+
+func (e *env) database() (proto.TemperatureClient, error) {
+	return e.dial(e.databaseAddr, e.tls.DatabaseServerName, servicetoken.Database)
+}
+
+func (e *env) close() {
+	for _, conn := range e.conns {
+		conn.Close()
+	}
+}
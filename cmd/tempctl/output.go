@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+	formatCSV   = "csv"
+)
+
+func validFormat(format string) bool {
+	return format == formatTable || format == formatJSON || format == formatCSV
+}
+
+// printer writes the results of a command one row at a time, so streams are
+// printed as they arrive. JSON is written as one object per line.
+type printer struct {
+	format  string
+	header  []string
+	table   *tabwriter.Writer
+	csv     *csv.Writer
+	json    io.Writer
+	started bool
+}
+
+func newPrinter(w io.Writer, format string, header ...string) *printer {
+	return &printer{
+		format: format,
+		header: header,
+		table:  tabwriter.NewWriter(w, 0, 0, 2, ' ', 0),
+		csv:    csv.NewWriter(w),
+		json:   w,
+	}
+}
+
+// Row prints a result. msg is used for JSON, columns for the table and CSV
+// and must follow the header.
+func (p *printer) Row(msg protoreflect.ProtoMessage, columns ...string) error {
+	switch p.format {
+	case formatJSON:
+		data, err := protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true}.Marshal(msg)
+		if err != nil {
+			return errors.Wrap(err, "failed to encode result")
+		}
+		_, err = fmt.Fprintf(p.json, "%s\n", data)
+		return err
+	case formatCSV:
+		if !p.started {
+			p.started = true
+			if err := p.csv.Write(p.header); err != nil {
+				return err
+			}
+		}
+		return p.csv.Write(columns)
+	default:
+		if !p.started {
+			p.started = true
+			fmt.Fprintln(p.table, strings.Join(p.header, "\t"))
+		}
+		fmt.Fprintln(p.table, strings.Join(columns, "\t"))
+		return nil
+	}
+}
+
+// Flush writes the buffered rows. The table is aligned on the rows printed
+// since the previous flush.
+func (p *printer) Flush() error {
+	switch p.format {
+	case formatCSV:
+		p.csv.Flush()
+		return p.csv.Error()
+	case formatTable:
+		if !p.started {
+			p.started = true
+			fmt.Fprintln(p.table, strings.Join(p.header, "\t"))
+		}
+		return p.table.Flush()
+	}
+	return nil
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func formatBool(b bool) string {
+	return strconv.FormatBool(b)
+}
+
+// formatUnix formats a Unix time in UTC, leaving unknown times empty.
+func formatUnix(seconds int64) string {
+	if seconds == 0 {
+		return ""
+	}
+	return time.Unix(seconds, 0).UTC().Format(time.RFC3339)
+}
+
+// formatEnum drops the prefix of a proto enum value, e.g.
+// ALERT_SEVERITY_WARNING becomes warning.
+func formatEnum(value, prefix string) string {
+	return strings.ToLower(strings.TrimPrefix(value, prefix))
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+
+	"github.com/pkg/errors"
+
+	"github.com/brochadoluis/temperature-exercise/proto"
+)
+
+var watchlistHeader = []string{"NAME", "LATITUDE", "LONGITUDE", "ADDED"}
+
+func watchlistRow(w *proto.WatchlistEntry) []string {
+	return []string{
+		w.GetName(),
+		formatFloat(w.GetLatitude()),
+		formatFloat(w.GetLongitude()),
+		formatUnix(w.GetCreatedAt()),
+	}
+}
+
+// runWatchlist manages the locations on the watchlist with the add, remove
+// and list subcommands.
+func runWatchlist(ctx context.Context, e *env, args []string) error {
+	if len(args) == 0 {
+		return errors.New("watchlist: expected add, remove or list")
+	}
+	switch args[0] {
+	case "add":
+		return runWatchlistAdd(ctx, e, args[1:])
+	case "remove":
+		return runWatchlistRemove(ctx, e, args[1:])
+	case "list":
+		return runWatchlistList(ctx, e, args[1:])
+	}
+	return errors.Errorf("watchlist: unknown subcommand %q, expected add, remove or list", args[0])
+}
+
+func runWatchlistAdd(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("watchlist add", flag.ContinueOnError)
+	name := fs.String("name", "", "name of the location")
+	latitude, longitude := coordinates(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireFlags(fs, "name", "latitude", "longitude"); err != nil {
+		return err
+	}
+
+	client, err := e.database()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, e.timeout)
+	defer cancel()
+
+	resp, err := client.AddToWatchlist(ctx, &proto.AddToWatchlistRequest{
+		Name:      *name,
+		Latitude:  *latitude,
+		Longitude: *longitude,
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to add to the watchlist")
+	}
+
+	p := newPrinter(os.Stdout, e.output, watchlistHeader...)
+	if err := p.Row(resp, watchlistRow(resp)...); err != nil {
+		return err
+	}
+	return p.Flush()
+}
+
+func runWatchlistRemove(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("watchlist remove", flag.ContinueOnError)
+	name := fs.String("name", "", "name of the location")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireFlags(fs, "name"); err != nil {
+		return err
+	}
+
+	client, err := e.database()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, e.timeout)
+	defer cancel()
+
+	if _, err := client.RemoveFromWatchlist(ctx, &proto.RemoveFromWatchlistRequest{Name: *name}); err != nil {
+		return errors.Wrap(err, "failed to remove from the watchlist")
+	}
+	return nil
+}
+
+// runWatchlistList prints the watchlist with the latest stored reading of
+// every location, so one command shows how the watched locations are doing.
+func runWatchlistList(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("watchlist list", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	client, err := e.database()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, e.timeout)
+	defer cancel()
+
+	resp, err := client.ListWatchlist(ctx, &proto.ListWatchlistRequest{})
+	if err != nil {
+		return errors.Wrap(err, "failed to list the watchlist")
+	}
+
+	p := newPrinter(os.Stdout, e.output, append(watchlistHeader, "TEMPERATURE", "ALERT", "TIME")...)
+	for _, w := range resp.GetEntries() {
+		// Locations without readings yet are listed with empty columns
+		row := append(watchlistRow(w), "", "", "")
+		if latest := w.GetLatest(); latest != nil {
+			row = append(watchlistRow(w),
+				formatFloat(latest.GetTemperature()),
+				formatBool(latest.GetAlert()),
+				formatUnix(latest.GetTimestamp()),
+			)
+		}
+		if err := p.Row(w, row...); err != nil {
+			return err
+		}
+	}
+	return p.Flush()
+}
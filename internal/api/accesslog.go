@@ -0,0 +1,26 @@
+package api
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// AccessLog logs every request once it is answered. Entries are created
+// with the request context, so they carry its request ID. Only the path is
+// logged, the query may hold an API key.
+func AccessLog(logger *logrus.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		logger.WithContext(c.Request.Context()).WithFields(logrus.Fields{
+			"method":    c.Request.Method,
+			"path":      c.Request.URL.Path,
+			"status":    c.Writer.Status(),
+			"latency":   time.Since(start).String(),
+			"client_ip": c.ClientIP(),
+		}).Info("HTTP request")
+	}
+}
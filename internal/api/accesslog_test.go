@@ -0,0 +1,45 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+
+	"github.com/brochadoluis/temperature-exercise/internal/requestid"
+)
+
+func TestAccessLog(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	logger, hook := test.NewNullLogger()
+	logger.AddHook(requestid.Hook{})
+	router := gin.New()
+	router.Use(requestid.Gin(), AccessLog(logger))
+	router.GET("/alerts/ws", func(c *gin.Context) {
+		c.Status(http.StatusUnauthorized)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/alerts/ws?api_key=secret", nil)
+	req.Header.Set(requestid.Header, "abc-123")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	entry := hook.LastEntry()
+	if entry == nil {
+		t.Fatal("No access log entry was written")
+	}
+	want := logrus.Fields{
+		requestid.LogField: "abc-123",
+		"method":           http.MethodGet,
+		"path":             "/alerts/ws",
+		"status":           http.StatusUnauthorized,
+	}
+	for field, value := range want {
+		if entry.Data[field] != value {
+			t.Errorf("Field %s is %v, want %v", field, entry.Data[field], value)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package api
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/brochadoluis/temperature-exercise/proto"
+)
+
+// CreateKeyRequest is the body of POST /admin/keys. Limits left out get the
+// configured defaults, 0 means unlimited.
+type CreateKeyRequest struct {
+	Name       string `binding:"required"`
+	RateLimit  *int32
+	DailyQuota *int32
+}
+
+// KeyResponse describes an API key. Key is only set when the key is created.
+type KeyResponse struct {
+	ID         string
+	Name       string
+	RateLimit  int32
+	DailyQuota int32
+	CreatedAt  time.Time
+	Key        string `json:",omitempty"`
+}
+
+// Admin serves the endpoints issuing and revoking API keys.
+type Admin struct {
+	client            proto.TemperatureClient
+	auth              *Authenticator
+	token             string
+	defaultRateLimit  int32
+	defaultDailyQuota int32
+}
+
+func NewAdmin(client proto.TemperatureClient, auth *Authenticator, token string, defaultRateLimit, defaultDailyQuota int) *Admin {
+	return &Admin{
+		client:            client,
+		auth:              auth,
+		token:             token,
+		defaultRateLimit:  int32(defaultRateLimit),
+		defaultDailyQuota: int32(defaultDailyQuota),
+	}
+}
+
+// Require rejects requests that do not carry the admin token as a bearer
+// token. The scheme is required, a bare token is rejected too.
+func (a *Admin) Require() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(a.token)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, ErrorResponse{Error: "Invalid admin token"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// CreateKey issues a new API key. The key is only returned in this response.
+func (a *Admin) CreateKey(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req CreateKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	rateLimit := a.defaultRateLimit
+	if req.RateLimit != nil {
+		rateLimit = *req.RateLimit
+	}
+	dailyQuota := a.defaultDailyQuota
+	if req.DailyQuota != nil {
+		dailyQuota = *req.DailyQuota
+	}
+
+	resp, err := a.client.CreateAPIKey(ctx, &proto.CreateAPIKeyRequest{
+		Name:       req.Name,
+		RateLimit:  rateLimit,
+		DailyQuota: dailyQuota,
+	})
+	if status.Code(err) == codes.InvalidArgument {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: status.Convert(err).Message()})
+		return
+	}
+	if err != nil {
+		log.WithContext(ctx).Errorf("Failed to create API key: %v", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to create API key"})
+		return
+	}
+
+	key := resp.GetApiKey()
+	c.JSON(http.StatusCreated, KeyResponse{
+		ID:         key.GetId(),
+		Name:       key.GetName(),
+		RateLimit:  key.GetRateLimit(),
+		DailyQuota: key.GetDailyQuota(),
+		CreatedAt:  time.Unix(key.GetCreatedAt(), 0).UTC(),
+		Key:        resp.GetKey(),
+	})
+}
+
+// RevokeKey revokes the API key given by the id path parameter.
+func (a *Admin) RevokeKey(c *gin.Context) {
+	ctx := c.Request.Context()
+	id := c.Param("id")
+
+	_, err := a.client.RevokeAPIKey(ctx, &proto.RevokeAPIKeyRequest{Id: id})
+	switch status.Code(err) {
+	case codes.OK:
+	case codes.InvalidArgument:
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: status.Convert(err).Message()})
+		return
+	case codes.NotFound:
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: status.Convert(err).Message()})
+		return
+	default:
+		log.WithContext(ctx).Errorf("Failed to revoke API key: %v", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to revoke API key"})
+		return
+	}
+
+	// Other API instances stop accepting the key once their cache expires
+	a.auth.Forget(id)
+	c.Status(http.StatusNoContent)
+}
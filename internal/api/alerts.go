@@ -0,0 +1,290 @@
+package api
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/brochadoluis/temperature-exercise/proto"
+)
+
+const (
+	// Time allowed to write a message to the client.
+	writeWait = 10 * time.Second
+	// Time allowed to read the next pong from the client.
+	pongWait = 60 * time.Second
+	// Interval between pings, must be less than pongWait.
+	pingPeriod = 30 * time.Second
+	// Maximum size of a message sent by the client.
+	maxMessageSize = 4096
+	// Number of messages queued for a client before it is considered slow
+	// and disconnected.
+	sendBuffer = 32
+)
+
+// Message types exchanged over the alerts WebSocket.
+const (
+	MessageSubscribe    = "subscribe"
+	MessageUnsubscribe  = "unsubscribe"
+	MessageSubscribed   = "subscribed"
+	MessageUnsubscribed = "unsubscribed"
+	MessageAlert        = "alert"
+	MessageError        = "error"
+)
+
+// ClientMessage is a message sent by a WebSocket client to change its
+// subscriptions.
+type ClientMessage struct {
+	Type       string   `json:"type"`
+	Latitude   float64  `json:"latitude,omitempty"`
+	Longitude  float64  `json:"longitude,omitempty"`
+	All        bool     `json:"all,omitempty"`
+	Severities []string `json:"severities,omitempty"`
+}
+
+// ServerMessage is a message sent to a WebSocket client.
+type ServerMessage struct {
+	Type        string   `json:"type"`
+	State       string   `json:"state,omitempty"`
+	Severity    string   `json:"severity,omitempty"`
+	Severities  []string `json:"severities,omitempty"`
+	Latitude    float64  `json:"latitude,omitempty"`
+	Longitude   float64  `json:"longitude,omitempty"`
+	All         bool     `json:"all,omitempty"`
+	Temperature float64  `json:"temperature,omitempty"`
+	Timestamp   int64    `json:"timestamp,omitempty"`
+	Message     string   `json:"message,omitempty"`
+}
+
+// AlertStream bridges WebSocket clients to the backend alert subscription RPC.
+type AlertStream struct {
+	client   proto.TemperatureClient
+	upgrader websocket.Upgrader
+	done     chan struct{}
+	once     sync.Once
+}
+
+func NewAlertStream(client proto.TemperatureClient) *AlertStream {
+	return &AlertStream{
+		client: client,
+		done:   make(chan struct{}),
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  1024,
+			WriteBufferSize: 1024,
+		},
+	}
+}
+
+// Shutdown closes every open WebSocket with a going away close frame.
+func (a *AlertStream) Shutdown() {
+	a.once.Do(func() {
+		close(a.done)
+	})
+}
+
+// Handle upgrades the request to a WebSocket and streams alert events until
+// either side closes the connection.
+func (a *AlertStream) Handle(c *gin.Context) {
+	logger := log.WithContext(c.Request.Context())
+
+	conn, err := a.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		logger.Errorf("Failed to upgrade connection: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	defer cancel()
+
+	stream, err := a.client.SubscribeAlerts(ctx)
+	if err != nil {
+		logger.Errorf("Failed to subscribe to alerts: %v", err)
+		writeClose(conn, websocket.CloseInternalServerErr, "alerts unavailable")
+		return
+	}
+
+	queue := newSendQueue(sendBuffer)
+
+	go a.readClient(logger, cancel, conn, stream, queue.enqueue)
+	go a.readBackend(ctx, logger, cancel, stream, queue.enqueue)
+
+	a.writeClient(ctx, logger, conn, queue.send, queue.slow)
+}
+
+// sendQueue buffers the messages to a client. Both the client and backend
+// readers enqueue, so slow is closed at most once.
+type sendQueue struct {
+	send chan ServerMessage
+	slow chan struct{}
+	once sync.Once
+}
+
+func newSendQueue(size int) *sendQueue {
+	return &sendQueue{
+		send: make(chan ServerMessage, size),
+		slow: make(chan struct{}),
+	}
+}
+
+// enqueue never blocks: a client that cannot keep up is disconnected
+// instead of stalling the backend stream.
+func (q *sendQueue) enqueue(msg ServerMessage) bool {
+	select {
+	case q.send <- msg:
+		return true
+	default:
+		q.once.Do(func() {
+			close(q.slow)
+		})
+		return false
+	}
+}
+
+func (a *AlertStream) readClient(logger *log.Entry, cancel context.CancelFunc, conn *websocket.Conn, stream proto.Temperature_SubscribeAlertsClient, enqueue func(ServerMessage) bool) {
+	defer cancel()
+
+	conn.SetReadLimit(maxMessageSize)
+	_ = conn.SetReadDeadline(time.Now().Add(pongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	for {
+		var msg ClientMessage
+		if err := conn.ReadJSON(&msg); err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				logger.Errorf("Failed to read client message: %v", err)
+			}
+			return
+		}
+
+		req, err := toSubscriptionRequest(msg)
+		if err != nil {
+			enqueue(ServerMessage{Type: MessageError, Message: err.Error()})
+			continue
+		}
+
+		if err := stream.Send(req); err != nil {
+			logger.Errorf("Failed to forward subscription: %v", err)
+			return
+		}
+
+		reply := ServerMessage{
+			Type:       MessageSubscribed,
+			Latitude:   msg.Latitude,
+			Longitude:  msg.Longitude,
+			All:        msg.All,
+			Severities: msg.Severities,
+		}
+		if msg.Type == MessageUnsubscribe {
+			reply.Type = MessageUnsubscribed
+		}
+		if !enqueue(reply) {
+			return
+		}
+	}
+}
+
+func (a *AlertStream) readBackend(ctx context.Context, logger *log.Entry, cancel context.CancelFunc, stream proto.Temperature_SubscribeAlertsClient, enqueue func(ServerMessage) bool) {
+	defer cancel()
+
+	for {
+		event, err := stream.Recv()
+		if err != nil {
+			if ctx.Err() == nil {
+				logger.Errorf("Alert subscription ended: %v", err)
+			}
+			return
+		}
+
+		if !enqueue(toAlertMessage(event)) {
+			return
+		}
+	}
+}
+
+func (a *AlertStream) writeClient(ctx context.Context, logger *log.Entry, conn *websocket.Conn, send <-chan ServerMessage, slow <-chan struct{}) {
+	ticker := time.NewTicker(pingPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case msg := <-send:
+			_ = conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := conn.WriteJSON(msg); err != nil {
+				logger.Errorf("Failed to write message: %v", err)
+				return
+			}
+		case <-ticker.C:
+			_ = conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		case <-slow:
+			logger.Warn("Disconnecting slow alerts client")
+			writeClose(conn, websocket.ClosePolicyViolation, "client too slow")
+			return
+		case <-a.done:
+			writeClose(conn, websocket.CloseGoingAway, "server shutting down")
+			return
+		case <-ctx.Done():
+			writeClose(conn, websocket.CloseNormalClosure, "")
+			return
+		}
+	}
+}
+
+func writeClose(conn *websocket.Conn, code int, text string) {
+	msg := websocket.FormatCloseMessage(code, text)
+	_ = conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(writeWait))
+}
+
+func toSubscriptionRequest(msg ClientMessage) (*proto.AlertSubscriptionRequest, error) {
+	req := &proto.AlertSubscriptionRequest{
+		Latitude:     msg.Latitude,
+		Longitude:    msg.Longitude,
+		AllLocations: msg.All,
+	}
+
+	switch msg.Type {
+	case MessageSubscribe:
+		req.Action = proto.SubscriptionAction_SUBSCRIPTION_ACTION_SUBSCRIBE
+	case MessageUnsubscribe:
+		req.Action = proto.SubscriptionAction_SUBSCRIPTION_ACTION_UNSUBSCRIBE
+	default:
+		return nil, errors.Errorf("unknown message type %q", msg.Type)
+	}
+
+	for _, name := range msg.Severities {
+		severity, ok := proto.AlertSeverity_value["ALERT_SEVERITY_"+strings.ToUpper(name)]
+		if !ok || severity == int32(proto.AlertSeverity_ALERT_SEVERITY_UNSPECIFIED) {
+			return nil, errors.Errorf("unknown severity %q", name)
+		}
+		req.Severities = append(req.Severities, proto.AlertSeverity(severity))
+	}
+
+	return req, nil
+}
+
+func toAlertMessage(event *proto.AlertEvent) ServerMessage {
+	return ServerMessage{
+		Type:        MessageAlert,
+		State:       enumName(event.GetState().String(), "ALERT_STATE_"),
+		Severity:    enumName(event.GetSeverity().String(), "ALERT_SEVERITY_"),
+		Latitude:    event.GetLatitude(),
+		Longitude:   event.GetLongitude(),
+		Temperature: event.GetTemperature(),
+		Timestamp:   event.GetTimestamp(),
+	}
+}
+
+func enumName(name, prefix string) string {
+	return strings.ToLower(strings.TrimPrefix(name, prefix))
+}
@@ -0,0 +1,38 @@
+package api
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestSendQueueFullFromBothReaders(t *testing.T) {
+	// The client and backend readers find the queue full at the same time,
+	// over many rounds so the race is likely to be hit
+	for round := 0; round < 1000; round++ {
+		queue := newSendQueue(1)
+		if !queue.enqueue(ServerMessage{Type: MessageAlert}) {
+			t.Fatal("Failed to enqueue into an empty queue")
+		}
+
+		start := make(chan struct{})
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				<-start
+				if queue.enqueue(ServerMessage{Type: MessageAlert}) {
+					t.Error("Enqueued into a full queue")
+				}
+			}()
+		}
+		close(start)
+		wg.Wait()
+
+		select {
+		case <-queue.slow:
+		default:
+			t.Fatal("A full queue did not mark the client as slow")
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/brochadoluis/temperature-exercise/proto"
+)
+
+// APIKeyHeader is the header clients send their API key in.
+const APIKeyHeader = "X-API-Key"
+
+// APIKeyQuery is the query parameter the alert stream also takes the API key
+// from, as browsers cannot set headers on WebSocket requests.
+const APIKeyQuery = "api_key"
+
+type apiKeyContextKey struct{}
+
+type cachedKey struct {
+	key     *proto.APIKey
+	expires time.Time
+}
+
+// usage counts the requests of a key in the current minute and UTC day.
+type usage struct {
+	minute      time.Time
+	minuteCount int
+	day         time.Time
+	dayCount    int
+}
+
+// Authenticator checks the API key of every request against the database
+// service and enforces the rate limit and daily quota of the key. Validated
+// keys are cached for a while so the database is not hit on every request.
+//
+// Usage is counted in memory, so the limits apply per API instance.
+type Authenticator struct {
+	client   proto.TemperatureClient
+	cacheTTL time.Duration
+
+	mu    sync.Mutex
+	keys  map[[sha256.Size]byte]cachedKey // by key hash
+	usage map[string]*usage               // by key ID
+}
+
+func NewAuthenticator(client proto.TemperatureClient, cacheTTL time.Duration) *Authenticator {
+	return &Authenticator{
+		client:   client,
+		cacheTTL: cacheTTL,
+		keys:     make(map[[sha256.Size]byte]cachedKey),
+		usage:    make(map[string]*usage),
+	}
+}
+
+// Require rejects requests without a valid API key with 401, and requests
+// over the limits of their key with 429 and a Retry-After header.
+func (a *Authenticator) Require() gin.HandlerFunc {
+	return a.require(false)
+}
+
+// RequireWebSocket is Require for WebSocket routes, which also take the key
+// from the api_key query parameter when the header is missing.
+func (a *Authenticator) RequireWebSocket() gin.HandlerFunc {
+	return a.require(true)
+}
+
+func (a *Authenticator) require(query bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+
+		key := c.GetHeader(APIKeyHeader)
+		if key == "" && query {
+			key = c.Query(APIKeyQuery)
+		}
+		if key == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, ErrorResponse{Error: "Missing API key"})
+			return
+		}
+
+		apiKey, err := a.lookup(ctx, key)
+		if status.Code(err) == codes.Unauthenticated {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, ErrorResponse{Error: "Invalid API key"})
+			return
+		}
+		if err != nil {
+			log.WithContext(ctx).Errorf("Failed to validate API key: %v", err)
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, ErrorResponse{Error: "Failed to validate API key"})
+			return
+		}
+
+		if retryAfter, reason := a.allow(apiKey, time.Now()); retryAfter > 0 {
+			log.WithContext(ctx).Warnf("API key %s exceeded its %s", apiKey.GetId(), reason)
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, ErrorResponse{Error: "Too many requests, " + reason + " exceeded"})
+			return
+		}
+
+		c.Request = c.Request.WithContext(context.WithValue(ctx, apiKeyContextKey{}, apiKey))
+		c.Next()
+	}
+}
+
+// lookup returns the key from the cache or validates it with the database
+// service.
+func (a *Authenticator) lookup(ctx context.Context, key string) (*proto.APIKey, error) {
+	// Only keep hashes of the keys in memory
+	hash := sha256.Sum256([]byte(key))
+
+	a.mu.Lock()
+	cached, ok := a.keys[hash]
+	a.mu.Unlock()
+	if ok && time.Now().Before(cached.expires) {
+		return cached.key, nil
+	}
+
+	apiKey, err := a.client.ValidateAPIKey(ctx, &proto.ValidateAPIKeyRequest{Key: key})
+	if err != nil {
+		return nil, err
+	}
+
+	a.mu.Lock()
+	a.keys[hash] = cachedKey{key: apiKey, expires: time.Now().Add(a.cacheTTL)}
+	a.mu.Unlock()
+
+	return apiKey, nil
+}
+
+// allow counts a request for the key. When the key is over one of its limits
+// the request is not counted, and allow returns how long until the limit
+// resets and which limit was hit.
+func (a *Authenticator) allow(key *proto.APIKey, now time.Time) (time.Duration, string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	u, ok := a.usage[key.GetId()]
+	if !ok {
+		u = &usage{}
+		a.usage[key.GetId()] = u
+	}
+
+	minute := now.Truncate(time.Minute)
+	if !u.minute.Equal(minute) {
+		u.minute, u.minuteCount = minute, 0
+	}
+	day := now.UTC().Truncate(24 * time.Hour)
+	if !u.day.Equal(day) {
+		u.day, u.dayCount = day, 0
+	}
+
+	if quota := int(key.GetDailyQuota()); quota > 0 && u.dayCount >= quota {
+		return day.Add(24 * time.Hour).Sub(now), "daily quota"
+	}
+	if limit := int(key.GetRateLimit()); limit > 0 && u.minuteCount >= limit {
+		return minute.Add(time.Minute).Sub(now), "rate limit"
+	}
+
+	u.minuteCount++
+	u.dayCount++
+	return 0, ""
+}
+
+// Charge counts one more request for the key of the request ctx belongs to,
+// for routes that keep costing upstream calls after they are answered. It
+// fails with ResourceExhausted once the key is over its limits, and does
+// nothing for requests without a key.
+func (a *Authenticator) Charge(ctx context.Context) error {
+	apiKey, ok := ctx.Value(apiKeyContextKey{}).(*proto.APIKey)
+	if !ok {
+		return nil
+	}
+	if retryAfter, reason := a.allow(apiKey, time.Now()); retryAfter > 0 {
+		log.WithContext(ctx).Warnf("API key %s exceeded its %s", apiKey.GetId(), reason)
+		return status.Errorf(codes.ResourceExhausted, "%s exceeded", reason)
+	}
+	return nil
+}
+
+// Forget drops the cached validation and usage of a key, so a revoked key
+// stops working immediately on this instance.
+func (a *Authenticator) Forget(id string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for hash, cached := range a.keys {
+		if cached.key.GetId() == id {
+			delete(a.keys, hash)
+		}
+	}
+	delete(a.usage, id)
+}
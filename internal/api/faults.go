@@ -0,0 +1,210 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/brochadoluis/temperature-exercise/internal/faults"
+	"github.com/brochadoluis/temperature-exercise/proto"
+)
+
+// Fault is the fault injected into a target, in the bodies of /admin/faults.
+type Fault struct {
+	// Target is one of open-meteo, scrapper-database, database and mongo.
+	Target    string `binding:"required"`
+	LatencyMs int64
+	// ErrorRate is the share of the calls that fail, between 0 and 1.
+	ErrorRate float64
+	// Code is the gRPC code of the failures of scrapper-database and
+	// database, UNAVAILABLE by default.
+	Code string `json:",omitempty"`
+	// HTTPStatus is the status of the failures of open-meteo. Failures
+	// without one get no response, like a connection error.
+	HTTPStatus int32 `json:",omitempty"`
+}
+
+// SetFaultsRequest is the body of PUT /admin/faults.
+type SetFaultsRequest struct {
+	Faults []Fault `binding:"dive"`
+}
+
+// FaultsResponse lists the faults injected by the services.
+type FaultsResponse struct {
+	Faults []Fault
+}
+
+// FaultAdmin serves the endpoints changing the faults injected by the
+// scrapper and database service. The services only accept faults when fault
+// injection is enabled in their configuration.
+type FaultAdmin struct {
+	scrapper proto.TemperatureClient
+	database proto.TemperatureClient
+}
+
+func NewFaultAdmin(scrapper, database proto.TemperatureClient) *FaultAdmin {
+	return &FaultAdmin{
+		scrapper: scrapper,
+		database: database,
+	}
+}
+
+// ListFaults lists the faults of both services.
+func (a *FaultAdmin) ListFaults(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	resp := FaultsResponse{Faults: []Fault{}}
+	for _, client := range []proto.TemperatureClient{a.scrapper, a.database} {
+		listed, err := client.ListFaults(ctx, &proto.ListFaultsRequest{})
+		if err != nil {
+			log.WithContext(ctx).Errorf("Failed to list faults: %v", err)
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to list faults"})
+			return
+		}
+		resp.Faults = append(resp.Faults, toFaults(listed.GetFaults())...)
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// SetFaults replaces the faults of both services with the ones of the
+// request. Targets left out stop failing.
+func (a *FaultAdmin) SetFaults(c *gin.Context) {
+	var req SetFaultsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	var scrapperFaults, databaseFaults []*proto.Fault
+	for _, f := range req.Faults {
+		m := &proto.Fault{
+			Target:     f.Target,
+			LatencyMs:  f.LatencyMs,
+			ErrorRate:  f.ErrorRate,
+			Code:       f.Code,
+			HttpStatus: f.HTTPStatus,
+		}
+		switch {
+		case contains(faults.ScrapperTargets, f.Target):
+			scrapperFaults = append(scrapperFaults, m)
+		case contains(faults.DatabaseTargets, f.Target):
+			databaseFaults = append(databaseFaults, m)
+		default:
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "unknown fault target " + f.Target})
+			return
+		}
+	}
+
+	// Both lists are checked before either service is called, so an invalid
+	// fault does not leave the scrapper with the new faults and the database
+	// service with the old ones
+	for _, list := range [][]*proto.Fault{scrapperFaults, databaseFaults} {
+		if err := validateFaults(list); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+	}
+
+	resp, ok := a.set(c, scrapperFaults, databaseFaults)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// ClearFaults stops the injection of every fault.
+func (a *FaultAdmin) ClearFaults(c *gin.Context) {
+	if _, ok := a.set(c, nil, nil); !ok {
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// set replaces the faults of the scrapper, then of the database service. It
+// answers the error itself and returns false when a service rejected them.
+// When the database service rejects its faults, the previous faults of the
+// scrapper are restored, so the services are never left half updated.
+func (a *FaultAdmin) set(c *gin.Context, scrapperFaults, databaseFaults []*proto.Fault) (FaultsResponse, bool) {
+	ctx := c.Request.Context()
+	logger := log.WithContext(ctx)
+
+	previous, err := a.scrapper.ListFaults(ctx, &proto.ListFaultsRequest{})
+	if err != nil {
+		logger.Errorf("Failed to list faults: %v", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to set faults"})
+		return FaultsResponse{}, false
+	}
+
+	scrapperSet, err := a.scrapper.SetFaults(ctx, &proto.SetFaultsRequest{Faults: scrapperFaults})
+	if err != nil {
+		faultError(ctx, c, err)
+		return FaultsResponse{}, false
+	}
+
+	databaseSet, err := a.database.SetFaults(ctx, &proto.SetFaultsRequest{Faults: databaseFaults})
+	if err != nil {
+		if _, restoreErr := a.scrapper.SetFaults(ctx, &proto.SetFaultsRequest{Faults: previous.GetFaults()}); restoreErr != nil {
+			logger.Errorf("Failed to restore the faults of the scrapper: %v", restoreErr)
+		}
+		faultError(ctx, c, err)
+		return FaultsResponse{}, false
+	}
+
+	resp := FaultsResponse{Faults: toFaults(scrapperSet.GetFaults())}
+	resp.Faults = append(resp.Faults, toFaults(databaseSet.GetFaults())...)
+	return resp, true
+}
+
+// validateFaults checks the faults of one service like the service does.
+func validateFaults(messages []*proto.Fault) error {
+	parsed, err := faults.FromProto(messages)
+	if err != nil {
+		return err
+	}
+	for target, f := range parsed {
+		if err := faults.Validate(target, f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// faultError answers an error of the SetFaults RPC.
+func faultError(ctx context.Context, c *gin.Context, err error) {
+	switch status.Code(err) {
+	case codes.InvalidArgument:
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: status.Convert(err).Message()})
+	case codes.FailedPrecondition:
+		c.JSON(http.StatusConflict, ErrorResponse{Error: status.Convert(err).Message()})
+	default:
+		log.WithContext(ctx).Errorf("Failed to set faults: %v", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to set faults"})
+	}
+}
+
+func toFaults(messages []*proto.Fault) []Fault {
+	result := make([]Fault, 0, len(messages))
+	for _, m := range messages {
+		result = append(result, Fault{
+			Target:     m.GetTarget(),
+			LatencyMs:  m.GetLatencyMs(),
+			ErrorRate:  m.GetErrorRate(),
+			Code:       m.GetCode(),
+			HTTPStatus: m.GetHttpStatus(),
+		})
+	}
+	return result
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
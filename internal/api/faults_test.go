@@ -0,0 +1,77 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+
+	"github.com/brochadoluis/temperature-exercise/internal/faults"
+	"github.com/brochadoluis/temperature-exercise/proto"
+)
+
+// faultClient serves the fault RPCs of a service from its injector.
+type faultClient struct {
+	proto.TemperatureClient
+	injector *faults.Injector
+}
+
+func (f faultClient) SetFaults(ctx context.Context, req *proto.SetFaultsRequest, _ ...grpc.CallOption) (*proto.SetFaultsResponse, error) {
+	return f.injector.SetFaults(ctx, req)
+}
+
+func (f faultClient) ListFaults(ctx context.Context, req *proto.ListFaultsRequest, _ ...grpc.CallOption) (*proto.ListFaultsResponse, error) {
+	return f.injector.ListFaults(ctx, req)
+}
+
+func TestSetFaultsLeavesScrapperUnchanged(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name            string
+		databaseEnabled bool
+		fault           Fault
+		wantStatus      int
+	}{
+		{"invalid database fault", true, Fault{Target: faults.Mongo, ErrorRate: 2}, http.StatusBadRequest},
+		{"database rejects faults", false, Fault{Target: faults.Mongo, ErrorRate: 1}, http.StatusConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scrapperFaults := faults.NewInjector(true, faults.ScrapperTargets...)
+			databaseFaults := faults.NewInjector(tt.databaseEnabled, faults.DatabaseTargets...)
+			previous := map[string]faults.Fault{faults.OpenMeteo: {Latency: 100 * time.Millisecond}}
+			if err := scrapperFaults.Set(previous); err != nil {
+				t.Fatalf("Failed to set the scrapper faults: %v", err)
+			}
+
+			admin := NewFaultAdmin(faultClient{injector: scrapperFaults}, faultClient{injector: databaseFaults})
+			router := gin.New()
+			router.PUT("/admin/faults", admin.SetFaults)
+
+			body, err := json.Marshal(SetFaultsRequest{Faults: []Fault{
+				{Target: faults.OpenMeteo, ErrorRate: 1},
+				tt.fault,
+			}})
+			if err != nil {
+				t.Fatalf("Failed to encode the request: %v", err)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/admin/faults", bytes.NewReader(body)))
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("PUT /admin/faults answered %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			got := scrapperFaults.Faults()
+			if len(got) != 1 || got[faults.OpenMeteo] != previous[faults.OpenMeteo] {
+				t.Errorf("Scrapper faults are %v, want %v", got, previous)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package api
+
+import (
+	"strings"
+
+	"github.com/brochadoluis/temperature-exercise/proto"
+)
+
+type ForecastResponse struct {
+	Latitude  float64
+	Longitude float64
+	Timezone  string
+	Hourly    []TimeSeries
+	Daily     []TimeSeries
+	Stored    bool
+}
+
+type TimeSeries struct {
+	Variable string
+	Unit     string
+	Points   []SeriesPoint
+}
+
+type SeriesPoint struct {
+	Time  string
+	Value float64
+}
+
+// ParseVariables splits comma separated query values into variable names.
+func ParseVariables(values []string) []string {
+	var variables []string
+	for _, value := range values {
+		for _, variable := range strings.Split(value, ",") {
+			if variable = strings.TrimSpace(variable); variable != "" {
+				variables = append(variables, variable)
+			}
+		}
+	}
+	return variables
+}
+
+func ToForecastResponse(f *proto.ListForecastResponse) ForecastResponse {
+	return ForecastResponse{
+		Latitude:  f.GetLatitude(),
+		Longitude: f.GetLongitude(),
+		Timezone:  f.GetTimezone(),
+		Hourly:    toTimeSeries(f.GetHourly()),
+		Daily:     toTimeSeries(f.GetDaily()),
+		Stored:    f.GetStored(),
+	}
+}
+
+func toTimeSeries(series []*proto.TimeSeries) []TimeSeries {
+	result := make([]TimeSeries, 0, len(series))
+	for _, ts := range series {
+		points := make([]SeriesPoint, 0, len(ts.GetPoints()))
+		for _, p := range ts.GetPoints() {
+			points = append(points, SeriesPoint{Time: p.GetTime(), Value: p.GetValue()})
+		}
+		result = append(result, TimeSeries{
+			Variable: ts.GetVariable(),
+			Unit:     ts.GetUnit(),
+			Points:   points,
+		})
+	}
+	return result
+}
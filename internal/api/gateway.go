@@ -0,0 +1,84 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/brochadoluis/temperature-exercise/proto"
+)
+
+// GatewayPrefix is where the routes generated from the google.api.http
+// annotations of the proto are mounted.
+const GatewayPrefix = "/api/"
+
+// NewGateway returns a grpc-gateway mux serving the HTTP routes annotated in
+// the proto, transcoding them to calls on client. Fields are named as in the
+// proto and always present, so the JSON matches the proto for every route.
+// Every reading of a watch after the first is charged to the API key of the
+// request through authenticator, the request itself paying for the first.
+func NewGateway(ctx context.Context, client proto.TemperatureClient, authenticator *Authenticator) (http.Handler, error) {
+	mux := runtime.NewServeMux(
+		runtime.WithMarshalerOption(runtime.MIMEWildcard, &runtime.JSONPb{
+			MarshalOptions: protojson.MarshalOptions{
+				UseProtoNames:   true,
+				EmitUnpopulated: true,
+			},
+			UnmarshalOptions: protojson.UnmarshalOptions{
+				DiscardUnknown: true,
+			},
+		}),
+		// Client headers must not reach the services as metadata, they could
+		// pose as service tokens. Request IDs and trace context are
+		// propagated by the client interceptors instead.
+		runtime.WithIncomingHeaderMatcher(func(string) (string, bool) {
+			return "", false
+		}),
+	)
+
+	client = meteredClient{TemperatureClient: client, authenticator: authenticator}
+	if err := proto.RegisterTemperatureHandlerClient(ctx, mux, client); err != nil {
+		return nil, errors.Wrap(err, "failed to register gateway routes")
+	}
+	return mux, nil
+}
+
+// meteredClient charges the readings of watch streams to the API key of the
+// request, so a stream cannot fetch from Open-Meteo for a single request.
+type meteredClient struct {
+	proto.TemperatureClient
+	authenticator *Authenticator
+}
+
+func (m meteredClient) WatchTemperature(ctx context.Context, req *proto.WatchTemperatureRequest, opts ...grpc.CallOption) (proto.Temperature_WatchTemperatureClient, error) {
+	stream, err := m.TemperatureClient.WatchTemperature(ctx, req, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &meteredWatch{Temperature_WatchTemperatureClient: stream, ctx: ctx, authenticator: m.authenticator}, nil
+}
+
+type meteredWatch struct {
+	proto.Temperature_WatchTemperatureClient
+	ctx           context.Context
+	authenticator *Authenticator
+	received      bool
+}
+
+func (w *meteredWatch) Recv() (*proto.ListTemperatureResponse, error) {
+	resp, err := w.Temperature_WatchTemperatureClient.Recv()
+	if err != nil {
+		return nil, err
+	}
+	if w.received {
+		if err := w.authenticator.Charge(w.ctx); err != nil {
+			return nil, err
+		}
+	}
+	w.received = true
+	return resp, nil
+}
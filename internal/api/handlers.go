@@ -0,0 +1,149 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/brochadoluis/temperature-exercise/internal/deadline"
+	"github.com/brochadoluis/temperature-exercise/internal/requestid"
+	"github.com/brochadoluis/temperature-exercise/internal/units"
+	"github.com/brochadoluis/temperature-exercise/proto"
+)
+
+// Handlers serves the temperature and forecast routes of every version of
+// the API. Versions share the calls to the scrapper and only differ in the
+// shape of their responses.
+type Handlers struct {
+	service    *Service
+	thresholds units.Thresholds
+}
+
+func NewHandlers(service *Service, thresholds units.Thresholds) *Handlers {
+	return &Handlers{
+		service:    service,
+		thresholds: thresholds,
+	}
+}
+
+// Deprecated marks the responses of a route kept for existing clients and
+// links the route replacing it.
+func Deprecated(successor string) gin.HandlerFunc {
+	link := fmt.Sprintf("<%s>; rel=\"successor-version\"", successor)
+	return func(c *gin.Context) {
+		c.Header("Deprecation", "true")
+		c.Header("Link", link)
+		c.Next()
+	}
+}
+
+// TemperatureV1 serves the current reading in the PascalCase shape of
+// GET /getTemperature.
+func (h *Handlers) TemperatureV1(c *gin.Context) {
+	t, u, ok := h.temperature(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, ToTemperatureResponse(t, u, h.thresholds))
+}
+
+// TemperatureV2 serves the current reading in a snake_case envelope.
+func (h *Handlers) TemperatureV2(c *gin.Context) {
+	t, u, ok := h.temperature(c)
+	if !ok {
+		return
+	}
+
+	observedAt := time.Now().UTC()
+	if t.GetObservedAt() > 0 {
+		observedAt = time.Unix(t.GetObservedAt(), 0).UTC()
+	}
+	c.JSON(http.StatusOK, TemperatureEnvelope{
+		Data:       ToTemperatureV2(t, u, h.thresholds),
+		Units:      u,
+		ObservedAt: observedAt,
+		RequestID:  requestid.FromContext(c.Request.Context()),
+	})
+}
+
+// ForecastV1 serves the forecast in the PascalCase shape of GET /forecast.
+func (h *Handlers) ForecastV1(c *gin.Context) {
+	f, ok := h.forecast(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, ToForecastResponse(f))
+}
+
+// ForecastV2 serves the forecast in a snake_case envelope. Open-Meteo answers
+// forecasts in metric units and observed_at is the time it was fetched.
+func (h *Handlers) ForecastV2(c *gin.Context) {
+	f, ok := h.forecast(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, ForecastEnvelope{
+		Data:       ToForecastV2(f),
+		Units:      units.Metric,
+		ObservedAt: time.Now().UTC(),
+		RequestID:  requestid.FromContext(c.Request.Context()),
+	})
+}
+
+// temperature reads the current temperature for the request. It answers the
+// error itself and returns false when the reading failed.
+func (h *Handlers) temperature(c *gin.Context) (*proto.ListTemperatureResponse, units.Units, bool) {
+	ctx := c.Request.Context()
+
+	u, err := units.Parse(c.Query("units"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return nil, "", false
+	}
+
+	resp, err := h.service.GetTemperature(ctx, c.Query("latitude"), c.Query("longitude"))
+	if err != nil {
+		log.WithContext(ctx).Errorf("Failed to get temperature: %v", err)
+		if deadline.Exceeded(err) {
+			c.JSON(http.StatusGatewayTimeout, ErrorResponse{Error: "Timed out getting temperature"})
+			return nil, "", false
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to get temperature"})
+		return nil, "", false
+	}
+	return resp, u, true
+}
+
+// forecast reads the forecast for the request. It answers the error itself
+// and returns false when the forecast failed.
+func (h *Handlers) forecast(c *gin.Context) (*proto.ListForecastResponse, bool) {
+	ctx := c.Request.Context()
+
+	resp, err := h.service.GetForecast(
+		ctx,
+		c.Query("latitude"),
+		c.Query("longitude"),
+		c.Query("days"),
+		ParseVariables(c.QueryArray("hourly")),
+		ParseVariables(c.QueryArray("daily")),
+		c.Query("store") == "true",
+	)
+	if err != nil {
+		log.WithContext(ctx).Errorf("Failed to get forecast: %v", err)
+		if errors.Is(err, ErrInvalidRequest) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return nil, false
+		}
+		if deadline.Exceeded(err) {
+			c.JSON(http.StatusGatewayTimeout, ErrorResponse{Error: "Timed out getting forecast"})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to get forecast"})
+		return nil, false
+	}
+	return resp, true
+}
@@ -0,0 +1,46 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/brochadoluis/temperature-exercise/internal/health"
+)
+
+// Health serves the liveness and readiness endpoints of the API.
+type Health struct {
+	checks map[string]health.Check
+}
+
+func NewHealth(checks map[string]health.Check) *Health {
+	return &Health{
+		checks: checks,
+	}
+}
+
+// Live reports that the process is up and serving HTTP.
+func (h *Health) Live(c *gin.Context) {
+	c.JSON(http.StatusOK, HealthResponse{Status: "ok"})
+}
+
+// Ready reports whether every downstream service is serving. It answers 503
+// with the failing dependencies otherwise.
+func (h *Health) Ready(c *gin.Context) {
+	status := http.StatusOK
+	dependencies := make(map[string]string, len(h.checks))
+	for name, err := range health.Evaluate(c.Request.Context(), h.checks) {
+		if err != nil {
+			status = http.StatusServiceUnavailable
+			dependencies[name] = err.Error()
+			continue
+		}
+		dependencies[name] = "ok"
+	}
+
+	result := "ok"
+	if status != http.StatusOK {
+		result = "unavailable"
+	}
+	c.JSON(status, HealthResponse{Status: result, Dependencies: dependencies})
+}
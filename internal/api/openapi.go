@@ -0,0 +1,525 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+// Security schemes an operation can require.
+const (
+	securityAPIKey      = "apiKey"
+	securityAPIKeyQuery = "apiKeyQuery"
+	securityAdmin       = "adminToken"
+)
+
+// Parameter documents a query or path parameter of an operation.
+type Parameter struct {
+	Name        string
+	In          string
+	Description string
+	Required    bool
+	// Type is an example value of the parameter type, e.g. "" or 0.0. Slices
+	// document repeatable parameters.
+	Type interface{}
+	// Enum lists the accepted values, if they are restricted.
+	Enum []string
+}
+
+// Response documents a response of an operation. Body is an example value of
+// the response type, nil for responses without a body.
+type Response struct {
+	Description string
+	Body        interface{}
+	Headers     map[string]string
+}
+
+// Operation documents a route of the HTTP API.
+type Operation struct {
+	Method      string
+	Path        string
+	ID          string
+	Summary     string
+	Description string
+	Tags        []string
+	Parameters  []Parameter
+	RequestBody interface{}
+	Responses   map[int]Response
+	// Security lists the schemes the operation may require. Optional
+	// schemes, like API keys when authentication is disabled, are marked as
+	// such in the document.
+	Security         []string
+	SecurityOptional bool
+	// Deprecated routes are kept for existing clients, Description names
+	// their successor.
+	Deprecated bool
+}
+
+var coordinates = []Parameter{
+	{Name: "latitude", In: "query", Required: true, Type: 0.0, Description: "Latitude in decimal degrees."},
+	{Name: "longitude", In: "query", Required: true, Type: 0.0, Description: "Longitude in decimal degrees."},
+}
+
+var errorResponses = map[int]Response{
+	http.StatusUnauthorized:        {Description: "Missing or invalid API key.", Body: ErrorResponse{}},
+	http.StatusTooManyRequests:     {Description: "Rate limit or daily quota of the API key exceeded.", Body: ErrorResponse{}, Headers: map[string]string{"Retry-After": "Seconds until the limit resets."}},
+	http.StatusInternalServerError: {Description: "The request failed.", Body: ErrorResponse{}},
+	http.StatusGatewayTimeout:      {Description: "The request did not complete within the deadline.", Body: ErrorResponse{}},
+}
+
+func withCoordinates(extra ...Parameter) []Parameter {
+	return append(append([]Parameter{}, coordinates...), extra...)
+}
+
+func withErrors(responses map[int]Response) map[int]Response {
+	for code, response := range errorResponses {
+		if _, ok := responses[code]; !ok {
+			responses[code] = response
+		}
+	}
+	return responses
+}
+
+var temperatureParameters = withCoordinates(Parameter{
+	Name: "units", In: "query", Type: "", Enum: []string{"metric", "imperial", "kelvin"},
+	Description: "Units of the temperature and thresholds, defaults to metric.",
+})
+
+var forecastParameters = withCoordinates(
+	Parameter{Name: "days", In: "query", Type: 0, Description: "Number of forecast days, 1 to 16. Defaults to 7."},
+	Parameter{Name: "hourly", In: "query", Type: []string{}, Description: "Open-Meteo hourly variables, defaults to temperature_2m."},
+	Parameter{Name: "daily", In: "query", Type: []string{}, Description: "Open-Meteo daily variables."},
+	Parameter{Name: "store", In: "query", Type: false, Description: "Store a snapshot of the forecast."},
+)
+
+// temperatureOperation documents a route serving the current reading with
+// body as its response.
+func temperatureOperation(path, id string, body interface{}) Operation {
+	return Operation{
+		Method:     http.MethodGet,
+		Path:       path,
+		ID:         id,
+		Summary:    "Current temperature and weather at a location",
+		Tags:       []string{"temperature"},
+		Parameters: temperatureParameters,
+		Responses: withErrors(map[int]Response{
+			http.StatusOK:         {Description: "The current reading.", Body: body},
+			http.StatusBadRequest: {Description: "Invalid units.", Body: ErrorResponse{}},
+		}),
+		Security:         []string{securityAPIKey},
+		SecurityOptional: true,
+	}
+}
+
+// forecastOperation documents a route serving the forecast with body as its
+// response.
+func forecastOperation(path, id string, body interface{}) Operation {
+	return Operation{
+		Method:     http.MethodGet,
+		Path:       path,
+		ID:         id,
+		Summary:    "Hourly and daily forecast at a location",
+		Tags:       []string{"temperature"},
+		Parameters: forecastParameters,
+		Responses: withErrors(map[int]Response{
+			http.StatusOK:         {Description: "The forecast.", Body: body},
+			http.StatusBadRequest: {Description: "Invalid parameters.", Body: ErrorResponse{}},
+		}),
+		Security:         []string{securityAPIKey},
+		SecurityOptional: true,
+	}
+}
+
+// deprecated marks op as replaced by the route at successor.
+func deprecated(op Operation, successor string) Operation {
+	op.Deprecated = true
+	op.Description = "Deprecated, use " + successor + " instead. Responses carry Deprecation and Link headers."
+	return op
+}
+
+// Operations documents every route of the HTTP API. Routes registered on the
+// router must be listed here, see CheckRoutes.
+var Operations = []Operation{
+	deprecated(temperatureOperation("/getTemperature", "getTemperature", TemperatureResponse{}), "/v1/getTemperature"),
+	deprecated(forecastOperation("/forecast", "getForecast", ForecastResponse{}), "/v1/forecast"),
+	temperatureOperation("/v1/getTemperature", "getTemperatureV1", TemperatureResponse{}),
+	forecastOperation("/v1/forecast", "getForecastV1", ForecastResponse{}),
+	temperatureOperation("/v2/temperature", "getTemperatureV2", TemperatureEnvelope{}),
+	forecastOperation("/v2/forecast", "getForecastV2", ForecastEnvelope{}),
+	{
+		Method:  http.MethodGet,
+		Path:    "/alerts/ws",
+		ID:      "subscribeAlerts",
+		Summary: "Alert notifications over a WebSocket",
+		Description: "Upgrades to a WebSocket. Clients send subscribe and unsubscribe messages and receive alert events, see the README. " +
+			"Browsers, which cannot set headers on WebSocket requests, may send the API key in the api_key query parameter.",
+		Tags: []string{"alerts"},
+		Responses: map[int]Response{
+			http.StatusSwitchingProtocols: {Description: "The connection was upgraded to a WebSocket."},
+			http.StatusUnauthorized:       errorResponses[http.StatusUnauthorized],
+			http.StatusTooManyRequests:    errorResponses[http.StatusTooManyRequests],
+		},
+		Security:         []string{securityAPIKey, securityAPIKeyQuery},
+		SecurityOptional: true,
+	},
+	{
+		Method:  http.MethodGet,
+		Path:    "/healthz",
+		ID:      "live",
+		Summary: "Liveness of the API",
+		Tags:    []string{"health"},
+		Responses: map[int]Response{
+			http.StatusOK: {Description: "The API is up.", Body: HealthResponse{}},
+		},
+	},
+	{
+		Method:  http.MethodGet,
+		Path:    "/readyz",
+		ID:      "ready",
+		Summary: "Readiness of the API and its dependencies",
+		Tags:    []string{"health"},
+		Responses: map[int]Response{
+			http.StatusOK:                 {Description: "Every dependency is serving.", Body: HealthResponse{}},
+			http.StatusServiceUnavailable: {Description: "A dependency is not serving.", Body: HealthResponse{}},
+		},
+	},
+	{
+		Method:      http.MethodPost,
+		Path:        "/admin/keys",
+		ID:          "createKey",
+		Summary:     "Issue an API key",
+		Description: "The key is only returned in this response. Only served when an admin token is configured.",
+		Tags:        []string{"admin"},
+		RequestBody: CreateKeyRequest{},
+		Responses: map[int]Response{
+			http.StatusCreated:             {Description: "The issued key.", Body: KeyResponse{}},
+			http.StatusBadRequest:          {Description: "Invalid request.", Body: ErrorResponse{}},
+			http.StatusUnauthorized:        {Description: "Invalid admin token.", Body: ErrorResponse{}},
+			http.StatusInternalServerError: errorResponses[http.StatusInternalServerError],
+		},
+		Security: []string{securityAdmin},
+	},
+	{
+		Method:  http.MethodDelete,
+		Path:    "/admin/keys/{id}",
+		ID:      "revokeKey",
+		Summary: "Revoke an API key",
+		Tags:    []string{"admin"},
+		Parameters: []Parameter{
+			{Name: "id", In: "path", Required: true, Type: "", Description: "ID of the key."},
+		},
+		Responses: map[int]Response{
+			http.StatusNoContent:           {Description: "The key was revoked."},
+			http.StatusBadRequest:          {Description: "Invalid ID.", Body: ErrorResponse{}},
+			http.StatusUnauthorized:        {Description: "Invalid admin token.", Body: ErrorResponse{}},
+			http.StatusNotFound:            {Description: "No active key with this ID.", Body: ErrorResponse{}},
+			http.StatusInternalServerError: errorResponses[http.StatusInternalServerError],
+		},
+		Security: []string{securityAdmin},
+	},
+	{
+		Method:      http.MethodGet,
+		Path:        "/admin/faults",
+		ID:          "listFaults",
+		Summary:     "List the injected faults",
+		Description: "Only served when an admin token is configured.",
+		Tags:        []string{"admin"},
+		Responses: map[int]Response{
+			http.StatusOK:                  {Description: "The faults of the scrapper and database service.", Body: FaultsResponse{}},
+			http.StatusUnauthorized:        {Description: "Invalid admin token.", Body: ErrorResponse{}},
+			http.StatusInternalServerError: errorResponses[http.StatusInternalServerError],
+		},
+		Security: []string{securityAdmin},
+	},
+	{
+		Method:  http.MethodPut,
+		Path:    "/admin/faults",
+		ID:      "setFaults",
+		Summary: "Replace the injected faults",
+		Description: "Targets left out stop failing. The services only accept faults when faults.enabled is set. " +
+			"Only served when an admin token is configured.",
+		Tags:        []string{"admin"},
+		RequestBody: SetFaultsRequest{},
+		Responses: map[int]Response{
+			http.StatusOK:                  {Description: "The faults now injected.", Body: FaultsResponse{}},
+			http.StatusBadRequest:          {Description: "Invalid fault.", Body: ErrorResponse{}},
+			http.StatusUnauthorized:        {Description: "Invalid admin token.", Body: ErrorResponse{}},
+			http.StatusConflict:            {Description: "Fault injection is disabled.", Body: ErrorResponse{}},
+			http.StatusInternalServerError: errorResponses[http.StatusInternalServerError],
+		},
+		Security: []string{securityAdmin},
+	},
+	{
+		Method:      http.MethodDelete,
+		Path:        "/admin/faults",
+		ID:          "clearFaults",
+		Summary:     "Stop injecting faults",
+		Description: "Only served when an admin token is configured.",
+		Tags:        []string{"admin"},
+		Responses: map[int]Response{
+			http.StatusNoContent:           {Description: "No fault is injected anymore."},
+			http.StatusUnauthorized:        {Description: "Invalid admin token.", Body: ErrorResponse{}},
+			http.StatusConflict:            {Description: "Fault injection is disabled.", Body: ErrorResponse{}},
+			http.StatusInternalServerError: errorResponses[http.StatusInternalServerError],
+		},
+		Security: []string{securityAdmin},
+	},
+}
+
+// undocumented lists the routes deliberately left out of the document.
+var undocumented = map[string]bool{
+	"GET /metrics":      true,
+	"GET /openapi.json": true,
+}
+
+// CheckRoutes returns an error naming the registered routes missing from
+// Operations, so the document cannot silently drift from the handlers.
+func CheckRoutes(routes gin.RoutesInfo) error {
+	documented := make(map[string]bool, len(Operations))
+	for _, op := range Operations {
+		documented[op.Method+" "+op.Path] = true
+	}
+
+	var missing []string
+	for _, route := range routes {
+		// Gateway routes are documented by the annotations of the proto
+		if strings.HasPrefix(route.Path, GatewayPrefix) {
+			continue
+		}
+		key := route.Method + " " + toOpenAPIPath(route.Path)
+		if !documented[key] && !undocumented[key] {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return errors.Errorf("routes missing from the OpenAPI document: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// toOpenAPIPath converts gin path parameters, :id, to OpenAPI ones, {id}.
+func toOpenAPIPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+var (
+	specOnce sync.Once
+	spec     []byte
+)
+
+// Spec returns the OpenAPI 3 document of the HTTP API, built from Operations
+// and the response types.
+func Spec() []byte {
+	specOnce.Do(func() {
+		spec, _ = json.MarshalIndent(buildSpec(), "", "  ")
+		spec = append(spec, '\n')
+	})
+	return spec
+}
+
+// ServeSpec serves the OpenAPI document.
+func ServeSpec(c *gin.Context) {
+	c.Data(http.StatusOK, "application/json", Spec())
+}
+
+type object = map[string]interface{}
+
+func buildSpec() object {
+	schemas := object{}
+
+	paths := object{}
+	for _, op := range Operations {
+		item, ok := paths[op.Path].(object)
+		if !ok {
+			item = object{}
+			paths[op.Path] = item
+		}
+		item[strings.ToLower(op.Method)] = buildOperation(op, schemas)
+	}
+
+	return object{
+		"openapi": "3.0.3",
+		"info": object{
+			"title":       "Temperature API",
+			"description": "Current temperatures, forecasts and alerts from Open-Meteo.",
+			"version":     "1.0.0",
+		},
+		"paths": paths,
+		"components": object{
+			"schemas": schemas,
+			"securitySchemes": object{
+				securityAPIKey:      object{"type": "apiKey", "in": "header", "name": APIKeyHeader},
+				securityAPIKeyQuery: object{"type": "apiKey", "in": "query", "name": APIKeyQuery},
+				securityAdmin:       object{"type": "http", "scheme": "bearer"},
+			},
+		},
+	}
+}
+
+func buildOperation(op Operation, schemas object) object {
+	result := object{
+		"operationId": op.ID,
+		"summary":     op.Summary,
+		"tags":        op.Tags,
+	}
+	if op.Description != "" {
+		result["description"] = op.Description
+	}
+	if op.Deprecated {
+		result["deprecated"] = true
+	}
+
+	if len(op.Parameters) > 0 {
+		params := make([]object, 0, len(op.Parameters))
+		for _, p := range op.Parameters {
+			schema := schemaFor(reflect.TypeOf(p.Type), schemas)
+			if len(p.Enum) > 0 {
+				schema["enum"] = p.Enum
+			}
+			param := object{
+				"name":     p.Name,
+				"in":       p.In,
+				"required": p.Required,
+				"schema":   schema,
+			}
+			if p.Description != "" {
+				param["description"] = p.Description
+			}
+			params = append(params, param)
+		}
+		result["parameters"] = params
+	}
+
+	if op.RequestBody != nil {
+		result["requestBody"] = object{
+			"required": true,
+			"content": object{
+				"application/json": object{"schema": schemaFor(reflect.TypeOf(op.RequestBody), schemas)},
+			},
+		}
+	}
+
+	responses := object{}
+	for code, r := range op.Responses {
+		response := object{"description": r.Description}
+		if r.Body != nil {
+			response["content"] = object{
+				"application/json": object{"schema": schemaFor(reflect.TypeOf(r.Body), schemas)},
+			}
+		}
+		if len(r.Headers) > 0 {
+			headers := object{}
+			for name, description := range r.Headers {
+				headers[name] = object{"description": description, "schema": object{"type": "integer"}}
+			}
+			response["headers"] = headers
+		}
+		responses[strconv.Itoa(code)] = response
+	}
+	result["responses"] = responses
+
+	if len(op.Security) > 0 {
+		var security []object
+		if op.SecurityOptional {
+			security = append(security, object{})
+		}
+		for _, scheme := range op.Security {
+			security = append(security, object{scheme: []string{}})
+		}
+		result["security"] = security
+	}
+
+	return result
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemaFor returns the JSON schema of t. Named structs are added to schemas
+// and referenced.
+func schemaFor(t reflect.Type, schemas object) object {
+	if t == timeType {
+		return object{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		schema := schemaFor(t.Elem(), schemas)
+		if _, ok := schema["$ref"]; !ok {
+			schema["nullable"] = true
+		}
+		return schema
+	case reflect.Bool:
+		return object{"type": "boolean"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16:
+		return object{"type": "integer", "format": "int32"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return object{"type": "integer", "format": "int64"}
+	case reflect.Float32, reflect.Float64:
+		return object{"type": "number", "format": "double"}
+	case reflect.String:
+		return object{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return object{"type": "array", "items": schemaFor(t.Elem(), schemas)}
+	case reflect.Map:
+		return object{"type": "object", "additionalProperties": schemaFor(t.Elem(), schemas)}
+	case reflect.Struct:
+		if _, ok := schemas[t.Name()]; !ok {
+			// Register the name first so recursive types terminate
+			schemas[t.Name()] = object{}
+			schemas[t.Name()] = structSchema(t, schemas)
+		}
+		return object{"$ref": "#/components/schemas/" + t.Name()}
+	}
+	return object{}
+}
+
+func structSchema(t reflect.Type, schemas object) object {
+	properties := object{}
+	var required []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, omitempty := field.Name, false
+		if tag, ok := field.Tag.Lookup("json"); ok {
+			if tag == "-" {
+				continue
+			}
+			parts := strings.Split(tag, ",")
+			if parts[0] != "" {
+				name = parts[0]
+			}
+			for _, option := range parts[1:] {
+				omitempty = omitempty || option == "omitempty"
+			}
+		}
+
+		properties[name] = schemaFor(field.Type, schemas)
+		if !omitempty && field.Type.Kind() != reflect.Ptr {
+			required = append(required, name)
+		}
+	}
+
+	schema := object{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
@@ -0,0 +1,17 @@
+package api
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+func TestSpecMatchesCheckedIn(t *testing.T) {
+	checkedIn, err := os.ReadFile("../../api/openapi.json")
+	if err != nil {
+		t.Fatalf("Failed to read the checked-in OpenAPI document: %v", err)
+	}
+	if !bytes.Equal(Spec(), checkedIn) {
+		t.Error("api/openapi.json differs from the served OpenAPI document, run make openapi")
+	}
+}
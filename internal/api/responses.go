@@ -0,0 +1,66 @@
+package api
+
+import (
+	"github.com/brochadoluis/temperature-exercise/internal/units"
+	"github.com/brochadoluis/temperature-exercise/proto"
+)
+
+// TemperatureResponse is the body of GET /getTemperature.
+type TemperatureResponse struct {
+	Latitude  float64
+	Longitude float64
+	// Temperature is given in Units.
+	Temperature     float64
+	Units           units.Units
+	TemperatureUnit string
+	AlertThresholds Thresholds
+	// WindSpeed is given in km/h and WindDirection in degrees.
+	WindSpeed     float64
+	WindDirection float64
+	// WeatherCode is the WMO weather interpretation code, Condition its
+	// description.
+	WeatherCode int32
+	Condition   string
+	IsDay       bool
+	Alert       bool
+	Error       bool
+}
+
+// Thresholds are the alert thresholds in the units of the response.
+type Thresholds struct {
+	Low  float64
+	High float64
+}
+
+// ErrorResponse is the body of every error answered by the API.
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// HealthResponse is the body of GET /healthz and GET /readyz. Dependencies
+// are only reported by /readyz.
+type HealthResponse struct {
+	Status       string            `json:"status"`
+	Dependencies map[string]string `json:"dependencies,omitempty"`
+}
+
+// ToTemperatureResponse converts a reading in Celsius to the response in the
+// units the client asked for.
+func ToTemperatureResponse(t *proto.ListTemperatureResponse, u units.Units, thresholds units.Thresholds) TemperatureResponse {
+	thresholds = thresholds.In(u)
+	return TemperatureResponse{
+		Latitude:        t.GetLatitude(),
+		Longitude:       t.GetLongitude(),
+		Temperature:     u.FromCelsius(t.GetTemperature()),
+		Units:           u,
+		TemperatureUnit: u.Symbol(),
+		AlertThresholds: Thresholds{Low: thresholds.Low, High: thresholds.High},
+		WindSpeed:       t.GetWindSpeed(),
+		WindDirection:   t.GetWindDirection(),
+		WeatherCode:     t.GetWeatherCode(),
+		Condition:       t.GetCondition(),
+		IsDay:           t.GetIsDay(),
+		Alert:           t.GetAlert(),
+		Error:           t.GetError(),
+	}
+}
@@ -0,0 +1,120 @@
+package api
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"google.golang.org/grpc"
+
+	"github.com/brochadoluis/temperature-exercise/internal/config"
+	"github.com/brochadoluis/temperature-exercise/internal/deadline"
+	"github.com/brochadoluis/temperature-exercise/internal/health"
+	"github.com/brochadoluis/temperature-exercise/internal/metrics"
+	"github.com/brochadoluis/temperature-exercise/internal/requestid"
+	"github.com/brochadoluis/temperature-exercise/internal/servicetoken"
+	"github.com/brochadoluis/temperature-exercise/internal/tracing"
+	"github.com/brochadoluis/temperature-exercise/proto"
+)
+
+// DialOptions returns the interceptors of the connections of the API to the
+// audience service. Every call is signed for the service it is dialing.
+func DialOptions(cfg *config.Config, signer *servicetoken.Signer, audience string) []grpc.DialOption {
+	return []grpc.DialOption{
+		grpc.WithChainUnaryInterceptor(requestid.UnaryClientInterceptor(), tracing.UnaryClientInterceptor(), metrics.UnaryClientInterceptor(), signer.UnaryClientInterceptor(audience), deadline.UnaryClientInterceptor(cfg.API.RequestTimeout)),
+		grpc.WithChainStreamInterceptor(requestid.StreamClientInterceptor(), tracing.StreamClientInterceptor(), metrics.StreamClientInterceptor(), signer.StreamClientInterceptor(audience)),
+	}
+}
+
+// NewRouter registers every route of the HTTP API, served by the scrapper and
+// database services behind the connections. The alert stream is returned so
+// its WebSockets can be closed on shutdown.
+func NewRouter(cfg *config.Config, scrapperConn, databaseConn *grpc.ClientConn, logger *logrus.Logger) (*gin.Engine, *AlertStream, error) {
+	scrapperClient := proto.NewTemperatureClient(scrapperConn)
+	apiService := NewAPIService(scrapperClient)
+	databaseClient := proto.NewTemperatureClient(databaseConn)
+	alertStream := NewAlertStream(databaseClient)
+	authenticator := NewAuthenticator(databaseClient, cfg.Auth.CacheTTL)
+	apiHealth := NewHealth(map[string]health.Check{
+		"scrapper": health.GRPC(scrapperConn),
+		"database": health.GRPC(databaseConn),
+	})
+
+	router := gin.New()
+
+	router.Use(gin.Recovery())
+	router.Use(requestid.Gin())
+	router.Use(AccessLog(logger))
+	router.Use(otelgin.Middleware("api"))
+	router.Use(metrics.Gin())
+
+	router.GET("/metrics", gin.WrapH(metrics.Handler()))
+	router.GET("/openapi.json", ServeSpec)
+
+	router.GET("/healthz", apiHealth.Live)
+	router.GET("/readyz", apiHealth.Ready)
+
+	// Routes that cost upstream requests require an API key when enabled
+	protected := router.Group("/")
+	if cfg.Auth.Enabled {
+		protected.Use(authenticator.Require())
+	}
+
+	// Deadlines only apply to the request/response routes, the alert stream
+	// lives as long as the client keeps it open
+	withDeadline := deadline.Gin(cfg.API.RequestTimeout)
+
+	handlers := NewHandlers(apiService, cfg.Alerts.Thresholds())
+
+	// The unversioned routes keep serving the v1 shape for existing clients.
+	// They and the v1 routes stay on the handlers rather than the gateway:
+	// their bodies are PascalCase, converted to the requested units and
+	// carry the alert thresholds, none of which the gateway can produce from
+	// the proto
+	protected.GET("/getTemperature", withDeadline, Deprecated("/v1/getTemperature"), handlers.TemperatureV1)
+	protected.GET("/forecast", withDeadline, Deprecated("/v1/forecast"), handlers.ForecastV1)
+
+	v1 := protected.Group("/v1", withDeadline)
+	v1.GET("/getTemperature", handlers.TemperatureV1)
+	v1.GET("/forecast", handlers.ForecastV1)
+
+	v2 := protected.Group("/v2", withDeadline)
+	v2.GET("/temperature", handlers.TemperatureV2)
+	v2.GET("/forecast", handlers.ForecastV2)
+
+	// Browsers cannot set headers on WebSocket requests, so the alert stream
+	// also takes the key from the query
+	streams := router.Group("/")
+	if cfg.Auth.Enabled {
+		streams.Use(authenticator.RequireWebSocket())
+	}
+	streams.GET("/alerts/ws", alertStream.Handle)
+
+	// Every RPC annotated in the proto gets a REST route under /api/
+	gateway, err := NewGateway(context.Background(), scrapperClient, authenticator)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to set up the gateway")
+	}
+	protected.Any(GatewayPrefix+"*path", gin.WrapH(gateway))
+
+	if cfg.Auth.AdminToken != "" {
+		admin := NewAdmin(databaseClient, authenticator, cfg.Auth.AdminToken, cfg.Auth.DefaultRateLimit, cfg.Auth.DefaultDailyQuota)
+		adminGroup := router.Group("/admin", withDeadline, admin.Require())
+		adminGroup.POST("/keys", admin.CreateKey)
+		adminGroup.DELETE("/keys/:id", admin.RevokeKey)
+
+		faultAdmin := NewFaultAdmin(scrapperClient, databaseClient)
+		adminGroup.GET("/faults", faultAdmin.ListFaults)
+		adminGroup.PUT("/faults", faultAdmin.SetFaults)
+		adminGroup.DELETE("/faults", faultAdmin.ClearFaults)
+	}
+
+	if err := CheckRoutes(router.Routes()); err != nil {
+		return nil, nil, errors.Wrap(err, "invalid OpenAPI document")
+	}
+
+	return router, alertStream, nil
+
+}
@@ -3,12 +3,18 @@ package api
 import (
 	"strconv"
 
+	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"github.com/brochadoluis/temperature-exercise/proto"
 )
 
+// ErrInvalidRequest is wrapped by errors caused by invalid request parameters.
+var ErrInvalidRequest = errors.New("invalid request")
+
 type Service struct {
 	client proto.TemperatureClient
 }
@@ -19,16 +25,16 @@ func NewAPIService(client proto.TemperatureClient) *Service {
 	}
 }
 
-func (s *Service) GetTemperature(latitude, longitude string) (*proto.ListTemperatureResponse, error) {
+func (s *Service) GetTemperature(ctx context.Context, latitude, longitude string) (*proto.ListTemperatureResponse, error) {
 	lat, err := strconv.ParseFloat(latitude, 64)
 	if err != nil {
-		log.Errorf("Failed to convert latitude: %v", err)
+		log.WithContext(ctx).Errorf("Failed to convert latitude: %v", err)
 		return &proto.ListTemperatureResponse{}, err
 	}
 
 	lng, err := strconv.ParseFloat(longitude, 64)
 	if err != nil {
-		log.Errorf("Failed to convert longitude: %v", err)
+		log.WithContext(ctx).Errorf("Failed to convert longitude: %v", err)
 		return &proto.ListTemperatureResponse{}, err
 	}
 	// Create a gRPC request
@@ -38,10 +44,52 @@ func (s *Service) GetTemperature(latitude, longitude string) (*proto.ListTempera
 	}
 
 	// Invoke the gRPC method on the client
-	resp, err := s.client.ListTemperature(context.Background(), req)
+	resp, err := s.client.ListTemperature(ctx, req)
 	if err != nil {
-		log.Errorf("Failed to call Method: %v", err)
+		log.WithContext(ctx).Errorf("Failed to call Method: %v", err)
 		return &proto.ListTemperatureResponse{}, err
 	}
 	return resp, nil
 }
+
+func (s *Service) GetForecast(ctx context.Context, latitude, longitude, days string, hourly, daily []string, store bool) (*proto.ListForecastResponse, error) {
+	lat, err := strconv.ParseFloat(latitude, 64)
+	if err != nil {
+		log.WithContext(ctx).Errorf("Failed to convert latitude: %v", err)
+		return nil, errors.Wrap(ErrInvalidRequest, "invalid latitude")
+	}
+
+	lng, err := strconv.ParseFloat(longitude, 64)
+	if err != nil {
+		log.WithContext(ctx).Errorf("Failed to convert longitude: %v", err)
+		return nil, errors.Wrap(ErrInvalidRequest, "invalid longitude")
+	}
+
+	var d int64
+	if days != "" {
+		d, err = strconv.ParseInt(days, 10, 32)
+		if err != nil {
+			log.WithContext(ctx).Errorf("Failed to convert days: %v", err)
+			return nil, errors.Wrap(ErrInvalidRequest, "invalid days")
+		}
+	}
+
+	req := &proto.ListForecastRequest{
+		Latitude:  lat,
+		Longitude: lng,
+		Days:      int32(d),
+		Hourly:    hourly,
+		Daily:     daily,
+		Store:     store,
+	}
+
+	resp, err := s.client.ListForecast(ctx, req)
+	if err != nil {
+		log.WithContext(ctx).Errorf("Failed to call Method: %v", err)
+		if status.Code(err) == codes.InvalidArgument {
+			return nil, errors.Wrap(ErrInvalidRequest, status.Convert(err).Message())
+		}
+		return nil, err
+	}
+	return resp, nil
+}
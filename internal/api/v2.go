@@ -0,0 +1,115 @@
+package api
+
+import (
+	"time"
+
+	"github.com/brochadoluis/temperature-exercise/internal/units"
+	"github.com/brochadoluis/temperature-exercise/proto"
+)
+
+// TemperatureEnvelope is the body of GET /v2/temperature.
+type TemperatureEnvelope struct {
+	Data  TemperatureV2 `json:"data"`
+	Units units.Units   `json:"units"`
+	// ObservedAt is when Open-Meteo took the reading, or when the API
+	// received it if Open-Meteo did not say.
+	ObservedAt time.Time `json:"observed_at"`
+	RequestID  string    `json:"request_id"`
+}
+
+// TemperatureV2 is the current reading, in the units of the envelope.
+type TemperatureV2 struct {
+	Latitude        float64      `json:"latitude"`
+	Longitude       float64      `json:"longitude"`
+	Temperature     float64      `json:"temperature"`
+	TemperatureUnit string       `json:"temperature_unit"`
+	AlertThresholds ThresholdsV2 `json:"alert_thresholds"`
+	// WindSpeed is given in km/h and WindDirection in degrees.
+	WindSpeed     float64 `json:"wind_speed"`
+	WindDirection float64 `json:"wind_direction"`
+	WeatherCode   int32   `json:"weather_code"`
+	Condition     string  `json:"condition"`
+	IsDay         bool    `json:"is_day"`
+	Alert         bool    `json:"alert"`
+	Error         bool    `json:"error"`
+}
+
+type ThresholdsV2 struct {
+	Low  float64 `json:"low"`
+	High float64 `json:"high"`
+}
+
+// ForecastEnvelope is the body of GET /v2/forecast.
+type ForecastEnvelope struct {
+	Data       ForecastV2  `json:"data"`
+	Units      units.Units `json:"units"`
+	ObservedAt time.Time   `json:"observed_at"`
+	RequestID  string      `json:"request_id"`
+}
+
+type ForecastV2 struct {
+	Latitude  float64        `json:"latitude"`
+	Longitude float64        `json:"longitude"`
+	Timezone  string         `json:"timezone"`
+	Hourly    []TimeSeriesV2 `json:"hourly"`
+	Daily     []TimeSeriesV2 `json:"daily"`
+	Stored    bool           `json:"stored"`
+}
+
+type TimeSeriesV2 struct {
+	Variable string          `json:"variable"`
+	Unit     string          `json:"unit"`
+	Points   []SeriesPointV2 `json:"points"`
+}
+
+type SeriesPointV2 struct {
+	Time  string  `json:"time"`
+	Value float64 `json:"value"`
+}
+
+// ToTemperatureV2 converts a reading in Celsius to the v2 reading in the units
+// the client asked for.
+func ToTemperatureV2(t *proto.ListTemperatureResponse, u units.Units, thresholds units.Thresholds) TemperatureV2 {
+	v1 := ToTemperatureResponse(t, u, thresholds)
+	return TemperatureV2{
+		Latitude:        v1.Latitude,
+		Longitude:       v1.Longitude,
+		Temperature:     v1.Temperature,
+		TemperatureUnit: v1.TemperatureUnit,
+		AlertThresholds: ThresholdsV2{Low: v1.AlertThresholds.Low, High: v1.AlertThresholds.High},
+		WindSpeed:       v1.WindSpeed,
+		WindDirection:   v1.WindDirection,
+		WeatherCode:     v1.WeatherCode,
+		Condition:       v1.Condition,
+		IsDay:           v1.IsDay,
+		Alert:           v1.Alert,
+		Error:           v1.Error,
+	}
+}
+
+func ToForecastV2(f *proto.ListForecastResponse) ForecastV2 {
+	return ForecastV2{
+		Latitude:  f.GetLatitude(),
+		Longitude: f.GetLongitude(),
+		Timezone:  f.GetTimezone(),
+		Hourly:    toTimeSeriesV2(f.GetHourly()),
+		Daily:     toTimeSeriesV2(f.GetDaily()),
+		Stored:    f.GetStored(),
+	}
+}
+
+func toTimeSeriesV2(series []*proto.TimeSeries) []TimeSeriesV2 {
+	result := make([]TimeSeriesV2, 0, len(series))
+	for _, ts := range series {
+		points := make([]SeriesPointV2, 0, len(ts.GetPoints()))
+		for _, p := range ts.GetPoints() {
+			points = append(points, SeriesPointV2{Time: p.GetTime(), Value: p.GetValue()})
+		}
+		result = append(result, TimeSeriesV2{
+			Variable: ts.GetVariable(),
+			Unit:     ts.GetUnit(),
+			Points:   points,
+		})
+	}
+	return result
+}
@@ -0,0 +1,565 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+
+	"github.com/brochadoluis/temperature-exercise/internal/units"
+)
+
+// EnvPrefix is prepended to the environment variable of every setting.
+const EnvPrefix = "TEMPERATURE_"
+
+// minSecretLength is the shortest HMAC secret accepted for service tokens.
+const minSecretLength = 32
+
+// Config holds the settings of the API, scrapper and database binaries.
+// Values are resolved with the following precedence, highest first: command
+// line flags, environment variables, the YAML file, and the defaults.
+type Config struct {
+	API         API         `yaml:"api"`
+	Scrapper    Scrapper    `yaml:"scrapper"`
+	Database    Database    `yaml:"database"`
+	Alerts      Alerts      `yaml:"alerts"`
+	Tracing     Tracing     `yaml:"tracing"`
+	Auth        Auth        `yaml:"auth"`
+	TLS         TLS         `yaml:"tls"`
+	ServiceAuth ServiceAuth `yaml:"service_auth"`
+	Faults      Faults      `yaml:"faults"`
+
+	// ShutdownTimeout bounds how long a binary drains in-flight work after
+	// receiving SIGINT or SIGTERM.
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+	// HealthInterval is how often the gRPC servers re-check their
+	// dependencies for the grpc.health.v1 status.
+	HealthInterval time.Duration `yaml:"health_interval"`
+
+	// File is the YAML file the configuration was loaded from, if any.
+	File string `yaml:"-"`
+	// PrintConfig asks the binary to print the resolved configuration and exit.
+	PrintConfig bool `yaml:"-"`
+}
+
+type API struct {
+	Addr         string `yaml:"addr"`
+	ScrapperAddr string `yaml:"scrapper_addr"`
+	DatabaseAddr string `yaml:"database_addr"`
+	// RequestTimeout is the end-to-end deadline of a request, propagated to
+	// the scrapper and database services.
+	RequestTimeout time.Duration `yaml:"request_timeout"`
+}
+
+type Scrapper struct {
+	Addr         string `yaml:"addr"`
+	MetricsAddr  string `yaml:"metrics_addr"`
+	DatabaseAddr string `yaml:"database_addr"`
+	OpenMeteoURL string `yaml:"open_meteo_url"`
+	// UpstreamTimeout caps a single request to Open-Meteo.
+	UpstreamTimeout time.Duration `yaml:"upstream_timeout"`
+	// FixturesMode is one of none, record or replay. In record mode the
+	// Open-Meteo responses are saved to FixturesDir, in replay mode they are
+	// served from it instead of calling Open-Meteo.
+	FixturesMode string `yaml:"fixtures_mode"`
+	FixturesDir  string `yaml:"fixtures_dir"`
+	// OutboxDir holds the readings the database service failed to save,
+	// until they are saved. The outbox is off when empty, the default.
+	OutboxDir string `yaml:"outbox_dir"`
+	// OutboxRetryInterval is how often the outbox is delivered.
+	OutboxRetryInterval time.Duration `yaml:"outbox_retry_interval"`
+	// OutboxMaxEntries caps the readings kept. Once reached, failed saves
+	// fail the request as without an outbox.
+	OutboxMaxEntries int `yaml:"outbox_max_entries"`
+}
+
+type Database struct {
+	Addr           string        `yaml:"addr"`
+	MetricsAddr    string        `yaml:"metrics_addr"`
+	MongoURI       string        `yaml:"mongo_uri"`
+	Name           string        `yaml:"name"`
+	ConnectTimeout time.Duration `yaml:"connect_timeout"`
+	// WriteTimeout caps a single MongoDB write.
+	WriteTimeout time.Duration `yaml:"write_timeout"`
+}
+
+type Alerts struct {
+	Low  float64 `yaml:"low"`
+	High float64 `yaml:"high"`
+}
+
+type Tracing struct {
+	// Exporter is one of none, stdout or otlp.
+	Exporter string `yaml:"exporter"`
+	// Endpoint is the OTLP gRPC collector address.
+	Endpoint    string  `yaml:"endpoint"`
+	Insecure    bool    `yaml:"insecure"`
+	SampleRatio float64 `yaml:"sample_ratio"`
+}
+
+// Auth configures the API key authentication of the HTTP API.
+type Auth struct {
+	// Enabled requires an API key on the temperature, forecast and alert
+	// routes.
+	Enabled bool `yaml:"enabled"`
+	// AdminToken protects the /admin endpoints. They are disabled when empty.
+	AdminToken string `yaml:"admin_token"`
+	// CacheTTL is how long a validated key is trusted before it is checked
+	// against the database service again.
+	CacheTTL time.Duration `yaml:"cache_ttl"`
+	// DefaultRateLimit and DefaultDailyQuota apply to keys issued without
+	// explicit limits.
+	DefaultRateLimit  int `yaml:"default_rate_limit"`
+	DefaultDailyQuota int `yaml:"default_daily_quota"`
+}
+
+// TLS secures the gRPC connections between the services. The same
+// certificate is served by the gRPC server of a binary and presented by its
+// clients in mtls mode, so it must be valid for both uses.
+type TLS struct {
+	// Mode is one of none, tls or mtls.
+	Mode string `yaml:"mode"`
+	// CAFile is the CA bundle peers are verified against.
+	CAFile   string `yaml:"ca_file"`
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+	// ScrapperServerName and DatabaseServerName override the name verified
+	// in the certificate of each service, which defaults to the host of the
+	// dialed address.
+	ScrapperServerName string `yaml:"scrapper_server_name"`
+	DatabaseServerName string `yaml:"database_server_name"`
+}
+
+// ServiceAuth configures the signed tokens the services attach to their gRPC
+// calls to each other.
+type ServiceAuth struct {
+	// Enabled makes clients sign their calls and servers reject writes
+	// without a valid token.
+	Enabled bool `yaml:"enabled"`
+	// SigningKeyID selects the key of Keys new tokens are signed with.
+	SigningKeyID string `yaml:"signing_key_id"`
+	// Keys are the HMAC secrets by key ID. Tokens signed with any of them are
+	// accepted, which allows rotating keys without downtime.
+	Keys map[string]string `yaml:"keys"`
+	// KeyIssuers binds key IDs to the only issuer they sign tokens for. Keys
+	// not bound only sign for the api, scrapper and database services, so
+	// tools like tempctl must be given a key of their own.
+	KeyIssuers map[string]string `yaml:"key_issuers"`
+	// TokenTTL is how long a signed token is valid.
+	TokenTTL time.Duration `yaml:"token_ttl"`
+}
+
+// Faults configures fault injection, for chaos testing. The faults themselves
+// are set at runtime through /admin/faults of the API.
+type Faults struct {
+	// Enabled lets the scrapper and database service inject faults. Leave it
+	// off outside of test environments.
+	Enabled bool `yaml:"enabled"`
+}
+
+// Thresholds returns the alert thresholds in Celsius.
+func (a Alerts) Thresholds() units.Thresholds {
+	return units.Thresholds{Low: a.Low, High: a.High}
+}
+
+// Default returns the configuration matching the docker-compose setup.
+func Default() *Config {
+	return &Config{
+		API: API{
+			Addr:           ":8080",
+			ScrapperAddr:   "scrapper:50051",
+			DatabaseAddr:   "server:50053",
+			RequestTimeout: 10 * time.Second,
+		},
+		Scrapper: Scrapper{
+			Addr:                ":50051",
+			MetricsAddr:         ":9090",
+			DatabaseAddr:        "server:50053",
+			OpenMeteoURL:        "https://api.open-meteo.com/v1/forecast",
+			UpstreamTimeout:     5 * time.Second,
+			FixturesMode:        "none",
+			FixturesDir:         "fixtures/open-meteo",
+			OutboxRetryInterval: 5 * time.Second,
+			OutboxMaxEntries:    10000,
+		},
+		Database: Database{
+			Addr:           ":50053",
+			MetricsAddr:    ":9090",
+			MongoURI:       "mongodb://database:27017",
+			Name:           "temperatures",
+			ConnectTimeout: 10 * time.Second,
+			WriteTimeout:   5 * time.Second,
+		},
+		Alerts: Alerts{
+			Low:  units.DefaultThresholds.Low,
+			High: units.DefaultThresholds.High,
+		},
+		Tracing: Tracing{
+			Exporter:    "none",
+			Endpoint:    "localhost:4317",
+			Insecure:    true,
+			SampleRatio: 1,
+		},
+		Auth: Auth{
+			CacheTTL:          time.Minute,
+			DefaultRateLimit:  60,
+			DefaultDailyQuota: 1000,
+		},
+		TLS: TLS{
+			Mode: "none",
+		},
+		ServiceAuth: ServiceAuth{
+			TokenTTL: time.Minute,
+		},
+		ShutdownTimeout: 15 * time.Second,
+		HealthInterval:  10 * time.Second,
+	}
+}
+
+// setting binds a flag and an environment variable to a configuration field.
+type setting struct {
+	name  string
+	usage string
+	set   func(c *Config, value string) error
+}
+
+func (s setting) env() string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(s.name, "-", "_"))
+}
+
+var settings = []setting{
+	{"api-addr", "address the HTTP API listens on", setString(func(c *Config) *string { return &c.API.Addr })},
+	{"api-scrapper-addr", "scrapper service address used by the API", setString(func(c *Config) *string { return &c.API.ScrapperAddr })},
+	{"api-database-addr", "database service address used by the API", setString(func(c *Config) *string { return &c.API.DatabaseAddr })},
+	{"api-request-timeout", "end-to-end deadline of an API request", setDuration(func(c *Config) *time.Duration { return &c.API.RequestTimeout })},
+	{"scrapper-addr", "address the scrapper gRPC server listens on", setString(func(c *Config) *string { return &c.Scrapper.Addr })},
+	{"scrapper-metrics-addr", "address the scrapper serves /metrics on", setString(func(c *Config) *string { return &c.Scrapper.MetricsAddr })},
+	{"scrapper-database-addr", "database service address used by the scrapper", setString(func(c *Config) *string { return &c.Scrapper.DatabaseAddr })},
+	{"scrapper-open-meteo-url", "Open-Meteo forecast endpoint", setString(func(c *Config) *string { return &c.Scrapper.OpenMeteoURL })},
+	{"scrapper-upstream-timeout", "timeout of a single Open-Meteo request", setDuration(func(c *Config) *time.Duration { return &c.Scrapper.UpstreamTimeout })},
+	{"scrapper-fixtures-mode", "Open-Meteo fixtures: none, record or replay", setString(func(c *Config) *string { return &c.Scrapper.FixturesMode })},
+	{"scrapper-fixtures-dir", "directory the Open-Meteo fixtures are recorded to and replayed from", setString(func(c *Config) *string { return &c.Scrapper.FixturesDir })},
+	{"scrapper-outbox-dir", "directory keeping the readings the database service failed to save, empty to drop them", setString(func(c *Config) *string { return &c.Scrapper.OutboxDir })},
+	{"scrapper-outbox-retry-interval", "how often saving the outbox readings is retried", setDuration(func(c *Config) *time.Duration { return &c.Scrapper.OutboxRetryInterval })},
+	{"scrapper-outbox-max-entries", "maximum number of readings kept in the outbox", setInt(func(c *Config) *int { return &c.Scrapper.OutboxMaxEntries })},
+	{"database-addr", "address the database gRPC server listens on", setString(func(c *Config) *string { return &c.Database.Addr })},
+	{"database-metrics-addr", "address the database service serves /metrics on", setString(func(c *Config) *string { return &c.Database.MetricsAddr })},
+	{"database-mongo-uri", "MongoDB connection string", setString(func(c *Config) *string { return &c.Database.MongoURI })},
+	{"database-name", "MongoDB database name", setString(func(c *Config) *string { return &c.Database.Name })},
+	{"database-connect-timeout", "timeout for connecting to MongoDB", setDuration(func(c *Config) *time.Duration { return &c.Database.ConnectTimeout })},
+	{"database-write-timeout", "timeout of a single MongoDB write", setDuration(func(c *Config) *time.Duration { return &c.Database.WriteTimeout })},
+	{"alerts-low", "temperature in Celsius below which an alert is raised", setFloat(func(c *Config) *float64 { return &c.Alerts.Low })},
+	{"alerts-high", "temperature in Celsius above which an alert is raised", setFloat(func(c *Config) *float64 { return &c.Alerts.High })},
+	{"tracing-exporter", "trace exporter: none, stdout or otlp", setString(func(c *Config) *string { return &c.Tracing.Exporter })},
+	{"tracing-endpoint", "OTLP gRPC collector address", setString(func(c *Config) *string { return &c.Tracing.Endpoint })},
+	{"tracing-insecure", "connect to the OTLP collector without TLS", setBool(func(c *Config) *bool { return &c.Tracing.Insecure })},
+	{"tracing-sample-ratio", "fraction of new traces that are sampled", setFloat(func(c *Config) *float64 { return &c.Tracing.SampleRatio })},
+	{"auth-enabled", "require an API key on the HTTP API", setBool(func(c *Config) *bool { return &c.Auth.Enabled })},
+	{"auth-admin-token", "token protecting the /admin endpoints", setString(func(c *Config) *string { return &c.Auth.AdminToken })},
+	{"auth-cache-ttl", "how long a validated API key is cached", setDuration(func(c *Config) *time.Duration { return &c.Auth.CacheTTL })},
+	{"auth-default-rate-limit", "requests per minute of keys issued without a limit", setInt(func(c *Config) *int { return &c.Auth.DefaultRateLimit })},
+	{"auth-default-daily-quota", "requests per day of keys issued without a quota", setInt(func(c *Config) *int { return &c.Auth.DefaultDailyQuota })},
+	{"tls-mode", "gRPC transport security: none, tls or mtls", setString(func(c *Config) *string { return &c.TLS.Mode })},
+	{"tls-ca-file", "CA bundle gRPC peers are verified against", setString(func(c *Config) *string { return &c.TLS.CAFile })},
+	{"tls-cert-file", "certificate of this service", setString(func(c *Config) *string { return &c.TLS.CertFile })},
+	{"tls-key-file", "private key of the certificate of this service", setString(func(c *Config) *string { return &c.TLS.KeyFile })},
+	{"tls-scrapper-server-name", "name expected in the certificate of the scrapper service", setString(func(c *Config) *string { return &c.TLS.ScrapperServerName })},
+	{"tls-database-server-name", "name expected in the certificate of the database service", setString(func(c *Config) *string { return &c.TLS.DatabaseServerName })},
+	{"service-auth-enabled", "sign and verify the gRPC calls between services", setBool(func(c *Config) *bool { return &c.ServiceAuth.Enabled })},
+	{"service-auth-signing-key-id", "ID of the key service tokens are signed with", setString(func(c *Config) *string { return &c.ServiceAuth.SigningKeyID })},
+	{"service-auth-keys", "service token keys as id=secret pairs separated by commas", setMap(func(c *Config) *map[string]string { return &c.ServiceAuth.Keys })},
+	{"service-auth-key-issuers", "issuers keys are bound to as id=issuer pairs separated by commas", setMap(func(c *Config) *map[string]string { return &c.ServiceAuth.KeyIssuers })},
+	{"service-auth-token-ttl", "validity of the service tokens", setDuration(func(c *Config) *time.Duration { return &c.ServiceAuth.TokenTTL })},
+	{"faults-enabled", "allow injecting faults at runtime, for chaos testing", setBool(func(c *Config) *bool { return &c.Faults.Enabled })},
+	{"shutdown-timeout", "time allowed to drain in-flight work on shutdown", setDuration(func(c *Config) *time.Duration { return &c.ShutdownTimeout })},
+	{"health-interval", "how often dependencies are checked for the health status", setDuration(func(c *Config) *time.Duration { return &c.HealthInterval })},
+}
+
+func setString(field func(c *Config) *string) func(*Config, string) error {
+	return func(c *Config, value string) error {
+		*field(c) = value
+		return nil
+	}
+}
+
+func setDuration(field func(c *Config) *time.Duration) func(*Config, string) error {
+	return func(c *Config, value string) error {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		*field(c) = d
+		return nil
+	}
+}
+
+func setBool(field func(c *Config) *bool) func(*Config, string) error {
+	return func(c *Config, value string) error {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		*field(c) = b
+		return nil
+	}
+}
+
+func setInt(field func(c *Config) *int) func(*Config, string) error {
+	return func(c *Config, value string) error {
+		i, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		*field(c) = i
+		return nil
+	}
+}
+
+func setMap(field func(c *Config) *map[string]string) func(*Config, string) error {
+	return func(c *Config, value string) error {
+		m, err := ParsePairs(value)
+		if err != nil {
+			return err
+		}
+		*field(c) = m
+		return nil
+	}
+}
+
+// ParsePairs parses key=value pairs separated by commas, the format of the
+// map settings such as service-auth-keys.
+func ParsePairs(value string) (map[string]string, error) {
+	m := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		k, v, ok := strings.Cut(pair, "=")
+		if !ok || k == "" {
+			return nil, errors.Errorf("invalid pair %q, expected key=value", pair)
+		}
+		m[k] = v
+	}
+	return m, nil
+}
+
+func setFloat(field func(c *Config) *float64) func(*Config, string) error {
+	return func(c *Config, value string) error {
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		*field(c) = f
+		return nil
+	}
+}
+
+// Load resolves the configuration from the command line arguments, the
+// environment and the optional YAML file given by --config or
+// TEMPERATURE_CONFIG, then validates it.
+func Load(name string, args []string) (*Config, error) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+
+	cfg := Default()
+	fs.StringVar(&cfg.File, "config", os.Getenv(EnvPrefix+"CONFIG"), "path to a YAML configuration file")
+	fs.BoolVar(&cfg.PrintConfig, "print-config", false, "print the resolved configuration and exit")
+
+	// Flags are only applied once the file and environment have been read
+	flagValues := make(map[string]string)
+	for _, s := range settings {
+		s := s
+		fs.Func(s.name, fmt.Sprintf("%s (env %s)", s.usage, s.env()), func(value string) error {
+			flagValues[s.name] = value
+			return nil
+		})
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if cfg.File != "" {
+		if err := cfg.loadFile(cfg.File); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, s := range settings {
+		value, ok := os.LookupEnv(s.env())
+		if !ok {
+			continue
+		}
+		if err := s.set(cfg, value); err != nil {
+			return nil, errors.Wrapf(err, "invalid %s", s.env())
+		}
+	}
+
+	for _, s := range settings {
+		value, ok := flagValues[s.name]
+		if !ok {
+			continue
+		}
+		if err := s.set(cfg, value); err != nil {
+			return nil, errors.Wrapf(err, "invalid --%s", s.name)
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return errors.Wrap(err, "failed to read config file")
+	}
+
+	if err := yaml.Unmarshal(data, c); err != nil {
+		return errors.Wrapf(err, "failed to parse config file %s", path)
+	}
+
+	return nil
+}
+
+// Validate checks that the configuration is usable.
+func (c *Config) Validate() error {
+	addrs := map[string]string{
+		"api.addr":               c.API.Addr,
+		"api.scrapper_addr":      c.API.ScrapperAddr,
+		"api.database_addr":      c.API.DatabaseAddr,
+		"scrapper.addr":          c.Scrapper.Addr,
+		"scrapper.metrics_addr":  c.Scrapper.MetricsAddr,
+		"scrapper.database_addr": c.Scrapper.DatabaseAddr,
+		"database.addr":          c.Database.Addr,
+		"database.metrics_addr":  c.Database.MetricsAddr,
+	}
+	for name, addr := range addrs {
+		if _, _, err := net.SplitHostPort(addr); err != nil {
+			return errors.Wrapf(err, "invalid %s", name)
+		}
+	}
+
+	u, err := url.Parse(c.Scrapper.OpenMeteoURL)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return errors.Errorf("invalid scrapper.open_meteo_url %q", c.Scrapper.OpenMeteoURL)
+	}
+
+	switch c.Scrapper.FixturesMode {
+	case "none":
+	case "record", "replay":
+		if c.Scrapper.FixturesDir == "" {
+			return errors.Errorf("scrapper.fixtures_dir is required when scrapper.fixtures_mode is %s", c.Scrapper.FixturesMode)
+		}
+	default:
+		return errors.Errorf("invalid scrapper.fixtures_mode %q, expected none, record or replay", c.Scrapper.FixturesMode)
+	}
+
+	if c.Scrapper.OutboxDir != "" && (c.Scrapper.OutboxRetryInterval <= 0 || c.Scrapper.OutboxMaxEntries <= 0) {
+		return errors.New("scrapper.outbox_retry_interval and scrapper.outbox_max_entries must be positive when scrapper.outbox_dir is set")
+	}
+
+	if !strings.HasPrefix(c.Database.MongoURI, "mongodb://") && !strings.HasPrefix(c.Database.MongoURI, "mongodb+srv://") {
+		return errors.Errorf("invalid database.mongo_uri %q", c.Database.MongoURI)
+	}
+
+	if c.Database.Name == "" {
+		return errors.New("database.name must not be empty")
+	}
+
+	timeouts := map[string]time.Duration{
+		"api.request_timeout":       c.API.RequestTimeout,
+		"scrapper.upstream_timeout": c.Scrapper.UpstreamTimeout,
+		"database.connect_timeout":  c.Database.ConnectTimeout,
+		"database.write_timeout":    c.Database.WriteTimeout,
+		"auth.cache_ttl":            c.Auth.CacheTTL,
+		"service_auth.token_ttl":    c.ServiceAuth.TokenTTL,
+	}
+	for name, timeout := range timeouts {
+		if timeout <= 0 {
+			return errors.Errorf("%s must be positive", name)
+		}
+	}
+
+	if c.Auth.DefaultRateLimit < 0 || c.Auth.DefaultDailyQuota < 0 {
+		return errors.New("auth.default_rate_limit and auth.default_daily_quota cannot be negative")
+	}
+
+	switch c.TLS.Mode {
+	case "none":
+	case "tls", "mtls":
+		files := map[string]string{
+			"tls.ca_file":   c.TLS.CAFile,
+			"tls.cert_file": c.TLS.CertFile,
+			"tls.key_file":  c.TLS.KeyFile,
+		}
+		for name, file := range files {
+			if file == "" {
+				return errors.Errorf("%s is required when tls.mode is %s", name, c.TLS.Mode)
+			}
+		}
+	default:
+		return errors.Errorf("invalid tls.mode %q, expected none, tls or mtls", c.TLS.Mode)
+	}
+
+	if c.ServiceAuth.Enabled {
+		if _, ok := c.ServiceAuth.Keys[c.ServiceAuth.SigningKeyID]; !ok {
+			return errors.Errorf("service_auth.signing_key_id %q is not in service_auth.keys", c.ServiceAuth.SigningKeyID)
+		}
+		for id, secret := range c.ServiceAuth.Keys {
+			if len(secret) < minSecretLength {
+				return errors.Errorf("service_auth.keys.%s must be at least %d bytes", id, minSecretLength)
+			}
+		}
+		for id := range c.ServiceAuth.KeyIssuers {
+			if _, ok := c.ServiceAuth.Keys[id]; !ok {
+				return errors.Errorf("service_auth.key_issuers.%s is not in service_auth.keys", id)
+			}
+		}
+	}
+
+	switch c.Tracing.Exporter {
+	case "none", "stdout":
+	case "otlp":
+		if _, _, err := net.SplitHostPort(c.Tracing.Endpoint); err != nil {
+			return errors.Wrap(err, "invalid tracing.endpoint")
+		}
+	default:
+		return errors.Errorf("invalid tracing.exporter %q, expected none, stdout or otlp", c.Tracing.Exporter)
+	}
+
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		return errors.New("tracing.sample_ratio must be between 0 and 1")
+	}
+
+	if c.ShutdownTimeout <= 0 {
+		return errors.New("shutdown_timeout must be positive")
+	}
+
+	if c.HealthInterval <= 0 {
+		return errors.New("health_interval must be positive")
+	}
+
+	if c.Alerts.Low >= c.Alerts.High {
+		return errors.Errorf("alerts.low (%v) must be lower than alerts.high (%v)", c.Alerts.Low, c.Alerts.High)
+	}
+
+	return nil
+}
+
+// Print writes the configuration as YAML.
+func (c *Config) Print(w io.Writer) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(c); err != nil {
+		return err
+	}
+	return enc.Close()
+}
@@ -0,0 +1,281 @@
+package database
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/brochadoluis/temperature-exercise/internal/metrics"
+	"github.com/brochadoluis/temperature-exercise/internal/units"
+	"github.com/brochadoluis/temperature-exercise/proto"
+)
+
+const (
+	// criticalMargin is how many degrees past a threshold a reading must be
+	// before the alert is considered critical instead of a warning.
+	criticalMargin = 5.0
+
+	// locationTolerance is the distance in degrees under which a subscribed
+	// location matches a reading. Open-Meteo snaps coordinates to its grid, so
+	// the saved coordinates rarely equal the ones a client asked for.
+	locationTolerance = 0.1
+
+	// subscriberBuffer is the number of events queued for a subscriber before
+	// it is considered too slow and dropped.
+	subscriberBuffer = 64
+
+	// alertHorizon is how old a reading may be and still change the alert
+	// state of its location. The newest reading of a location only needs to
+	// be remembered for as long, so locations are forgotten after it.
+	alertHorizon = time.Hour
+	// pruneInterval is how often forgotten locations are removed.
+	pruneInterval = time.Minute
+)
+
+type location struct {
+	latitude  float64
+	longitude float64
+}
+
+func (l location) matches(latitude, longitude float64) bool {
+	return math.Abs(l.latitude-latitude) <= locationTolerance &&
+		math.Abs(l.longitude-longitude) <= locationTolerance
+}
+
+type subscription struct {
+	allLocations bool
+	location     location
+	severities   map[proto.AlertSeverity]bool
+}
+
+func (s subscription) matches(event *proto.AlertEvent) bool {
+	if len(s.severities) > 0 && !s.severities[event.GetSeverity()] {
+		return false
+	}
+	return s.allLocations || s.location.matches(event.GetLatitude(), event.GetLongitude())
+}
+
+// Subscriber receives the alert events matching its subscriptions.
+type Subscriber struct {
+	mu            sync.Mutex
+	subscriptions map[location]subscription
+	all           *subscription
+	events        chan *proto.AlertEvent
+	dropped       chan struct{}
+	once          sync.Once
+}
+
+// Events returns the channel the subscriber's alert events are delivered on.
+func (s *Subscriber) Events() <-chan *proto.AlertEvent {
+	return s.events
+}
+
+// Dropped is closed when the subscriber fell too far behind and was removed.
+func (s *Subscriber) Dropped() <-chan struct{} {
+	return s.dropped
+}
+
+// Apply adds or removes a subscription according to the request.
+func (s *Subscriber) Apply(req *proto.AlertSubscriptionRequest) {
+	sub := subscription{
+		allLocations: req.GetAllLocations(),
+		location:     location{latitude: req.GetLatitude(), longitude: req.GetLongitude()},
+		severities:   make(map[proto.AlertSeverity]bool),
+	}
+	for _, severity := range req.GetSeverities() {
+		sub.severities[severity] = true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch req.GetAction() {
+	case proto.SubscriptionAction_SUBSCRIPTION_ACTION_SUBSCRIBE:
+		if sub.allLocations {
+			s.all = &sub
+			return
+		}
+		s.subscriptions[sub.location] = sub
+	case proto.SubscriptionAction_SUBSCRIPTION_ACTION_UNSUBSCRIBE:
+		if sub.allLocations {
+			s.all = nil
+			return
+		}
+		delete(s.subscriptions, sub.location)
+	}
+}
+
+func (s *Subscriber) wants(event *proto.AlertEvent) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.all != nil && s.all.matches(event) {
+		return true
+	}
+	for _, sub := range s.subscriptions {
+		if sub.matches(event) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Subscriber) drop() {
+	s.once.Do(func() {
+		close(s.dropped)
+	})
+}
+
+// AlertBroker tracks the alert state of every location and fans out open and
+// resolve transitions to its subscribers.
+type AlertBroker struct {
+	thresholds units.Thresholds
+	mu         sync.Mutex
+	// open holds the event that opened the alert of each location, or
+	// changed its severity last.
+	open map[location]*proto.AlertEvent
+	// latest holds the time of the newest reading observed at each
+	// location within the alert horizon, so readings saved late cannot
+	// override newer ones.
+	latest      map[location]time.Time
+	pruned      time.Time
+	subscribers map[*Subscriber]struct{}
+	now         func() time.Time
+}
+
+func NewAlertBroker(thresholds units.Thresholds) *AlertBroker {
+	return &AlertBroker{
+		thresholds:  thresholds,
+		open:        make(map[location]*proto.AlertEvent),
+		latest:      make(map[location]time.Time),
+		subscribers: make(map[*Subscriber]struct{}),
+		now:         time.Now,
+	}
+}
+
+// Subscribe registers a new subscriber without any subscriptions.
+func (b *AlertBroker) Subscribe() *Subscriber {
+	sub := &Subscriber{
+		subscriptions: make(map[location]subscription),
+		events:        make(chan *proto.AlertEvent, subscriberBuffer),
+		dropped:       make(chan struct{}),
+	}
+
+	b.mu.Lock()
+	b.subscribers[sub] = struct{}{}
+	b.mu.Unlock()
+
+	return sub
+}
+
+// Unsubscribe removes the subscriber from the broker.
+func (b *AlertBroker) Unsubscribe(sub *Subscriber) {
+	b.mu.Lock()
+	delete(b.subscribers, sub)
+	b.mu.Unlock()
+}
+
+// Observe records a reading taken at the given time and publishes an event if
+// the alert state of its location changed. Readings older than the newest one
+// observed at their location are ignored, as the scrapper outbox can save
+// readings after newer ones, and so are readings older than the alert
+// horizon.
+func (b *AlertBroker) Observe(latitude, longitude, temperature float64, alert bool, takenAt time.Time) {
+	loc := location{latitude: latitude, longitude: longitude}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	horizon := b.now().Add(-alertHorizon)
+	b.prune(horizon)
+	if takenAt.Before(horizon) || takenAt.Before(b.latest[loc]) {
+		return
+	}
+	b.latest[loc] = takenAt
+
+	previous, wasOpen := b.open[loc]
+
+	var event *proto.AlertEvent
+	switch {
+	case alert:
+		severity := b.severityFor(temperature)
+		if wasOpen && previous.GetSeverity() == severity {
+			return
+		}
+		event = newAlertEvent(loc, temperature, proto.AlertState_ALERT_STATE_OPEN, severity)
+		b.open[loc] = event
+		metrics.SetActiveAlerts(len(b.open))
+	case wasOpen:
+		delete(b.open, loc)
+		metrics.SetActiveAlerts(len(b.open))
+		event = newAlertEvent(loc, temperature, proto.AlertState_ALERT_STATE_RESOLVED, previous.GetSeverity())
+	default:
+		return
+	}
+
+	for sub := range b.subscribers {
+		if !sub.wants(event) {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			// The subscriber is not keeping up, drop it rather than block
+			// every other subscriber and the write path.
+			delete(b.subscribers, sub)
+			sub.drop()
+		}
+	}
+}
+
+// prune forgets the locations without a reading since the horizon, at most
+// once per prune interval.
+func (b *AlertBroker) prune(horizon time.Time) {
+	if horizon.Sub(b.pruned) < pruneInterval {
+		return
+	}
+	b.pruned = horizon
+
+	for loc, takenAt := range b.latest {
+		if takenAt.Before(horizon) {
+			delete(b.latest, loc)
+		}
+	}
+}
+
+// Active returns the event of every open alert, sorted by location.
+func (b *AlertBroker) Active() []*proto.AlertEvent {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	events := make([]*proto.AlertEvent, 0, len(b.open))
+	for _, event := range b.open {
+		events = append(events, event)
+	}
+	sort.Slice(events, func(i, j int) bool {
+		if events[i].GetLatitude() != events[j].GetLatitude() {
+			return events[i].GetLatitude() < events[j].GetLatitude()
+		}
+		return events[i].GetLongitude() < events[j].GetLongitude()
+	})
+	return events
+}
+
+func newAlertEvent(loc location, temperature float64, state proto.AlertState, severity proto.AlertSeverity) *proto.AlertEvent {
+	return &proto.AlertEvent{
+		Latitude:    loc.latitude,
+		Longitude:   loc.longitude,
+		Temperature: temperature,
+		State:       state,
+		Severity:    severity,
+		Timestamp:   time.Now().Unix(),
+	}
+}
+
+func (b *AlertBroker) severityFor(temperature float64) proto.AlertSeverity {
+	if temperature < b.thresholds.Low-criticalMargin || temperature > b.thresholds.High+criticalMargin {
+		return proto.AlertSeverity_ALERT_SEVERITY_CRITICAL
+	}
+	return proto.AlertSeverity_ALERT_SEVERITY_WARNING
+}
@@ -0,0 +1,54 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/brochadoluis/temperature-exercise/internal/units"
+)
+
+func TestAlertBrokerForgetsLocations(t *testing.T) {
+	now := time.Now()
+	broker := NewAlertBroker(units.Thresholds{Low: 0, High: 30})
+	broker.now = func() time.Time { return now }
+
+	// Every reading comes from a new location, as with uniformly drawn
+	// coordinates, for longer than the horizon
+	const step = 10 * time.Second
+	for i := 0; i < 1000; i++ {
+		broker.Observe(float64(i)/100, 0, 20, false, now)
+		now = now.Add(step)
+	}
+	if len(broker.latest) > int((alertHorizon+pruneInterval)/step)+1 {
+		t.Errorf("The broker remembers %d locations, want those of the last %s", len(broker.latest), alertHorizon+pruneInterval)
+	}
+	now = now.Add(alertHorizon + pruneInterval)
+	broker.Observe(50, 50, 20, false, now)
+	if len(broker.latest) != 1 {
+		t.Errorf("The broker remembers %d locations after the horizon, want 1", len(broker.latest))
+	}
+}
+
+func TestAlertBrokerIgnoresLateReadings(t *testing.T) {
+	now := time.Now()
+	broker := NewAlertBroker(units.Thresholds{Low: 0, High: 30})
+	broker.now = func() time.Time { return now }
+
+	tests := []struct {
+		name    string
+		takenAt time.Time
+		alert   bool
+		open    int
+	}{
+		{"newest reading opens the alert", now, true, 1},
+		{"older reading cannot resolve it", now.Add(-time.Minute), false, 1},
+		{"newer reading resolves it", now.Add(time.Second), false, 0},
+		{"reading past the horizon cannot open it", now.Add(-2 * alertHorizon), true, 0},
+	}
+	for _, tt := range tests {
+		broker.Observe(38.7, -9.1, 40, tt.alert, tt.takenAt)
+		if got := len(broker.Active()); got != tt.open {
+			t.Errorf("%s: %d open alerts, want %d", tt.name, got, tt.open)
+		}
+	}
+}
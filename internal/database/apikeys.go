@@ -0,0 +1,135 @@
+package database
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/brochadoluis/temperature-exercise/proto"
+)
+
+// APIKeysCollection holds the API keys issued to the clients of the HTTP API.
+const APIKeysCollection = "api_keys"
+
+// keyPrefix makes the keys recognisable when they leak into logs or
+// repositories.
+const keyPrefix = "tk_"
+
+// apiKey is the document stored for an API key. Only the SHA-256 hash of the
+// key is stored.
+type apiKey struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty"`
+	Name       string             `bson:"name"`
+	Hash       string             `bson:"hash"`
+	RateLimit  int32              `bson:"rate_limit"`
+	DailyQuota int32              `bson:"daily_quota"`
+	CreatedAt  time.Time          `bson:"created_at"`
+	RevokedAt  *time.Time         `bson:"revoked_at,omitempty"`
+}
+
+func (k *apiKey) toProto() *proto.APIKey {
+	return &proto.APIKey{
+		Id:         k.ID.Hex(),
+		Name:       k.Name,
+		RateLimit:  k.RateLimit,
+		DailyQuota: k.DailyQuota,
+		CreatedAt:  k.CreatedAt.Unix(),
+	}
+}
+
+// hashAPIKey returns the hash an API key is stored and looked up by.
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func generateAPIKey() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Wrap(err, "failed to generate API key")
+	}
+	return keyPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func (s *Service) CreateAPIKey(ctx context.Context, req *proto.CreateAPIKeyRequest) (*proto.CreateAPIKeyResponse, error) {
+	logger := logrus.WithContext(ctx)
+
+	if req.GetName() == "" {
+		return nil, status.Error(codes.InvalidArgument, "name is required")
+	}
+	if req.GetRateLimit() < 0 || req.GetDailyQuota() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "limits cannot be negative")
+	}
+
+	key, err := generateAPIKey()
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &apiKey{
+		Name:       req.GetName(),
+		Hash:       hashAPIKey(key),
+		RateLimit:  req.GetRateLimit(),
+		DailyQuota: req.GetDailyQuota(),
+		// Mongo stores milliseconds, truncate so the response matches the document
+		CreatedAt: time.Now().UTC().Truncate(time.Millisecond),
+	}
+	err = s.insert(ctx, APIKeysCollection, func(ctx context.Context) error {
+		return s.store.InsertAPIKey(ctx, doc)
+	})
+	if err != nil {
+		logger.Errorf("Failed to save API key to %s collection: %v", APIKeysCollection, err)
+		return nil, err
+	}
+
+	logger.Infof("Created API key %s for %s", doc.ID.Hex(), doc.Name)
+
+	return &proto.CreateAPIKeyResponse{ApiKey: doc.toProto(), Key: key}, nil
+}
+
+func (s *Service) RevokeAPIKey(ctx context.Context, req *proto.RevokeAPIKeyRequest) (*proto.RevokeAPIKeyResponse, error) {
+	logger := logrus.WithContext(ctx)
+
+	id, err := primitive.ObjectIDFromHex(req.GetId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid API key id")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.writeTimeout)
+	defer cancel()
+
+	err = s.store.RevokeAPIKey(ctx, id, time.Now().UTC())
+	if errors.Is(err, ErrNotFound) {
+		return nil, status.Error(codes.NotFound, "API key not found or already revoked")
+	}
+	if err != nil {
+		logger.Errorf("Failed to revoke API key %s: %v", req.GetId(), err)
+		return nil, err
+	}
+
+	logger.Infof("Revoked API key %s", req.GetId())
+
+	return &proto.RevokeAPIKeyResponse{}, nil
+}
+
+func (s *Service) ValidateAPIKey(ctx context.Context, req *proto.ValidateAPIKeyRequest) (*proto.APIKey, error) {
+	doc, err := s.store.FindAPIKey(ctx, hashAPIKey(req.GetKey()))
+	if errors.Is(err, ErrNotFound) {
+		return nil, status.Error(codes.Unauthenticated, "invalid API key")
+	}
+	if err != nil {
+		logrus.WithContext(ctx).Errorf("Failed to look up API key: %v", err)
+		return nil, err
+	}
+
+	return doc.toProto(), nil
+}
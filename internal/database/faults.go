@@ -0,0 +1,56 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/brochadoluis/temperature-exercise/internal/faults"
+	"github.com/brochadoluis/temperature-exercise/proto"
+)
+
+// faultyStore fails the writes to the store it wraps as configured for the
+// Mongo target. Reads are left alone.
+type faultyStore struct {
+	Store
+	faults *faults.Injector
+}
+
+func (s *faultyStore) InsertReading(ctx context.Context, collection string, r *reading) error {
+	if err := s.faults.Error(ctx, faults.Mongo); err != nil {
+		return err
+	}
+	return s.Store.InsertReading(ctx, collection, r)
+}
+
+func (s *faultyStore) InsertForecast(ctx context.Context, f *forecastSnapshot) (string, error) {
+	if err := s.faults.Error(ctx, faults.Mongo); err != nil {
+		return "", err
+	}
+	return s.Store.InsertForecast(ctx, f)
+}
+
+func (s *faultyStore) InsertAPIKey(ctx context.Context, k *apiKey) error {
+	if err := s.faults.Error(ctx, faults.Mongo); err != nil {
+		return err
+	}
+	return s.Store.InsertAPIKey(ctx, k)
+}
+
+func (s *faultyStore) RevokeAPIKey(ctx context.Context, id primitive.ObjectID, at time.Time) error {
+	if err := s.faults.Error(ctx, faults.Mongo); err != nil {
+		return err
+	}
+	return s.Store.RevokeAPIKey(ctx, id, at)
+}
+
+// SetFaults replaces the faults injected by the database service.
+func (s *Service) SetFaults(ctx context.Context, req *proto.SetFaultsRequest) (*proto.SetFaultsResponse, error) {
+	return s.faults.SetFaults(ctx, req)
+}
+
+// ListFaults returns the faults injected by the database service.
+func (s *Service) ListFaults(ctx context.Context, req *proto.ListFaultsRequest) (*proto.ListFaultsResponse, error) {
+	return s.faults.ListFaults(ctx, req)
+}
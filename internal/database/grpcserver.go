@@ -0,0 +1,59 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/brochadoluis/temperature-exercise/internal/config"
+	"github.com/brochadoluis/temperature-exercise/internal/deadline"
+	"github.com/brochadoluis/temperature-exercise/internal/faults"
+	"github.com/brochadoluis/temperature-exercise/internal/health"
+	"github.com/brochadoluis/temperature-exercise/internal/metrics"
+	"github.com/brochadoluis/temperature-exercise/internal/requestid"
+	"github.com/brochadoluis/temperature-exercise/internal/servicetoken"
+	"github.com/brochadoluis/temperature-exercise/internal/tracing"
+	"github.com/brochadoluis/temperature-exercise/proto"
+)
+
+// GRPCServer is the database service served over gRPC, with its health
+// service.
+type GRPCServer struct {
+	*grpc.Server
+	Health *grpchealth.Server
+
+	checks   map[string]health.Check
+	interval time.Duration
+}
+
+// NewGRPCServer registers the database service keeping its documents in
+// store on a gRPC server with the interceptors and health service of every
+// deployment. The service serves while the checks pass. opts carry the
+// transport security, if any.
+func NewGRPCServer(cfg *config.Config, store Store, checks map[string]health.Check, opts ...grpc.ServerOption) *GRPCServer {
+	injector := faults.NewInjector(cfg.Faults.Enabled, faults.DatabaseTargets...)
+	verifier := servicetoken.NewVerifier(servicetoken.Database, cfg.ServiceAuth, Policy)
+	server := grpc.NewServer(append(opts,
+		grpc.ChainUnaryInterceptor(requestid.UnaryServerInterceptor(), tracing.UnaryServerInterceptor(), metrics.UnaryServerInterceptor(), verifier.UnaryServerInterceptor(), injector.UnaryServerInterceptor(faults.Database), deadline.UnaryServerInterceptor()),
+		grpc.ChainStreamInterceptor(requestid.StreamServerInterceptor(), tracing.StreamServerInterceptor(), metrics.StreamServerInterceptor(), verifier.StreamServerInterceptor()),
+	)...)
+	proto.RegisterTemperatureServer(server, NewService(store, cfg.Alerts.Thresholds(), cfg.Database.WriteTimeout, injector))
+
+	healthServer := grpchealth.NewServer()
+	healthpb.RegisterHealthServer(server, healthServer)
+
+	return &GRPCServer{
+		Server:   server,
+		Health:   healthServer,
+		checks:   checks,
+		interval: cfg.HealthInterval,
+	}
+}
+
+// Run checks the dependencies until ctx is done.
+func (s *GRPCServer) Run(ctx context.Context) {
+	health.Run(ctx, s.Health, s.interval, s.checks)
+}
@@ -0,0 +1,136 @@
+package database
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryStore keeps the documents in memory, for running the whole system in
+// one process without MongoDB. Everything is lost when the process exits.
+type MemoryStore struct {
+	mu        sync.Mutex
+	readings  map[string][]reading
+	forecasts []forecastSnapshot
+	keys      map[primitive.ObjectID]*apiKey
+	watchlist map[string]watch
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		readings:  make(map[string][]reading),
+		keys:      make(map[primitive.ObjectID]*apiKey),
+		watchlist: make(map[string]watch),
+	}
+}
+
+var _ Store = (*MemoryStore)(nil)
+
+func (m *MemoryStore) InsertReading(ctx context.Context, collection string, r *reading) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.readings[collection] = append(m.readings[collection], *r)
+	return nil
+}
+
+func (m *MemoryStore) FindReadings(ctx context.Context, collection string, q readingQuery) ([]reading, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Readings saved late by the outbox are appended after newer ones, so
+	// sort on the time they were taken, newest first, as MongoStore does
+	var docs []reading
+	for _, r := range m.readings[collection] {
+		if q.matches(&r) {
+			docs = append(docs, r)
+		}
+	}
+	sort.SliceStable(docs, func(i, j int) bool {
+		return docs[i].Timestamp.After(docs[j].Timestamp)
+	})
+	if int64(len(docs)) > q.Limit {
+		docs = docs[:q.Limit]
+	}
+	return docs, nil
+}
+
+func (m *MemoryStore) InsertForecast(ctx context.Context, f *forecastSnapshot) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.forecasts = append(m.forecasts, *f)
+	return primitive.NewObjectID().Hex(), nil
+}
+
+func (m *MemoryStore) InsertAPIKey(ctx context.Context, k *apiKey) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	k.ID = primitive.NewObjectID()
+	stored := *k
+	m.keys[k.ID] = &stored
+	return nil
+}
+
+func (m *MemoryStore) RevokeAPIKey(ctx context.Context, id primitive.ObjectID, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	k, ok := m.keys[id]
+	if !ok || k.RevokedAt != nil {
+		return ErrNotFound
+	}
+	k.RevokedAt = &at
+	return nil
+}
+
+func (m *MemoryStore) FindAPIKey(ctx context.Context, hash string) (*apiKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, k := range m.keys {
+		if k.Hash == hash && k.RevokedAt == nil {
+			found := *k
+			return &found, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (m *MemoryStore) InsertWatch(ctx context.Context, w *watch) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.watchlist[w.Name]; ok {
+		return ErrAlreadyExists
+	}
+	m.watchlist[w.Name] = *w
+	return nil
+}
+
+func (m *MemoryStore) DeleteWatch(ctx context.Context, name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.watchlist[name]; !ok {
+		return ErrNotFound
+	}
+	delete(m.watchlist, name)
+	return nil
+}
+
+func (m *MemoryStore) FindWatches(ctx context.Context) ([]watch, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	docs := make([]watch, 0, len(m.watchlist))
+	for _, w := range m.watchlist {
+		docs = append(docs, w)
+	}
+	sort.Slice(docs, func(i, j int) bool { return docs[i].Name < docs[j].Name })
+	return docs, nil
+}
@@ -0,0 +1,144 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoStore keeps the documents in MongoDB, one collection per kind.
+type MongoStore struct {
+	db *mongo.Database
+}
+
+func NewMongoStore(db *mongo.Database) *MongoStore {
+	return &MongoStore{
+		db: db,
+	}
+}
+
+var _ Store = (*MongoStore)(nil)
+
+func (m *MongoStore) InsertReading(ctx context.Context, collection string, r *reading) error {
+	_, err := m.db.Collection(collection).InsertOne(ctx, r)
+	return err
+}
+
+func (m *MongoStore) FindReadings(ctx context.Context, collection string, q readingQuery) ([]reading, error) {
+	filter := bson.M{}
+	if !q.AllLocations {
+		filter["request.latitude"] = bson.M{
+			"$gte": q.Latitude - locationTolerance,
+			"$lte": q.Latitude + locationTolerance,
+		}
+		filter["request.longitude"] = bson.M{
+			"$gte": q.Longitude - locationTolerance,
+			"$lte": q.Longitude + locationTolerance,
+		}
+	}
+	if !q.Since.IsZero() {
+		filter["timestamp"] = bson.M{"$gte": q.Since}
+	}
+
+	cursor, err := m.db.Collection(collection).Find(ctx, filter,
+		options.Find().SetSort(bson.D{{Key: "timestamp", Value: -1}}).SetLimit(q.Limit))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var docs []reading
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	return docs, nil
+}
+
+func (m *MongoStore) InsertForecast(ctx context.Context, f *forecastSnapshot) (string, error) {
+	result, err := m.db.Collection("forecast").InsertOne(ctx, f)
+	if err != nil {
+		return "", err
+	}
+
+	id := ""
+	if oid, ok := result.InsertedID.(primitive.ObjectID); ok {
+		id = oid.Hex()
+	}
+	return id, nil
+}
+
+func (m *MongoStore) InsertAPIKey(ctx context.Context, k *apiKey) error {
+	result, err := m.db.Collection(APIKeysCollection).InsertOne(ctx, k)
+	if err != nil {
+		return err
+	}
+	k.ID, _ = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (m *MongoStore) RevokeAPIKey(ctx context.Context, id primitive.ObjectID, at time.Time) error {
+	result, err := m.db.Collection(APIKeysCollection).UpdateOne(ctx,
+		bson.M{"_id": id, "revoked_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revoked_at": at}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (m *MongoStore) FindAPIKey(ctx context.Context, hash string) (*apiKey, error) {
+	var doc apiKey
+	err := m.db.Collection(APIKeysCollection).FindOne(ctx, bson.M{
+		"hash":       hash,
+		"revoked_at": bson.M{"$exists": false},
+	}).Decode(&doc)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+func (m *MongoStore) InsertWatch(ctx context.Context, w *watch) error {
+	_, err := m.db.Collection(WatchlistCollection).InsertOne(ctx, w)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrAlreadyExists
+	}
+	return err
+}
+
+func (m *MongoStore) DeleteWatch(ctx context.Context, name string) error {
+	result, err := m.db.Collection(WatchlistCollection).DeleteOne(ctx, bson.M{"name": name})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (m *MongoStore) FindWatches(ctx context.Context) ([]watch, error) {
+	cursor, err := m.db.Collection(WatchlistCollection).Find(ctx, bson.M{},
+		options.Find().SetSort(bson.D{{Key: "name", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	docs := []watch{}
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	return docs, nil
+}
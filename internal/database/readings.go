@@ -0,0 +1,65 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/brochadoluis/temperature-exercise/internal/tracing"
+	"github.com/brochadoluis/temperature-exercise/proto"
+)
+
+const (
+	defaultReadingsLimit = 100
+	maxReadingsLimit     = 1000
+)
+
+// ListReadings returns the stored readings, newest first. Locations match
+// within the same tolerance as alert subscriptions.
+func (s *Service) ListReadings(ctx context.Context, req *proto.ListReadingsRequest) (*proto.ListReadingsResponse, error) {
+	ctx, span := tracing.Start(ctx, "database.find readings")
+	defer span.End()
+
+	collection := "success"
+	if req.GetErrors() {
+		collection = "error"
+	}
+
+	limit := int64(req.GetLimit())
+	if limit <= 0 {
+		limit = defaultReadingsLimit
+	}
+	if limit > maxReadingsLimit {
+		limit = maxReadingsLimit
+	}
+
+	q := readingQuery{
+		Latitude:     req.GetLatitude(),
+		Longitude:    req.GetLongitude(),
+		AllLocations: req.GetAllLocations(),
+		Limit:        limit,
+	}
+	if req.GetSince() > 0 {
+		q.Since = time.Unix(req.GetSince(), 0)
+	}
+
+	docs, err := s.store.FindReadings(ctx, collection, q)
+	if err != nil {
+		logrus.WithContext(ctx).Errorf("Failed to list readings from %s collection: %v", collection, err)
+		return nil, err
+	}
+
+	resp := &proto.ListReadingsResponse{Readings: make([]*proto.Reading, 0, len(docs))}
+	for i := range docs {
+		resp.Readings = append(resp.Readings, docs[i].toProto())
+	}
+	return resp, nil
+}
+
+// ListActiveAlerts returns the alerts currently open. Alert state is kept in
+// memory, so only the alerts seen by this instance since it started are
+// listed.
+func (s *Service) ListActiveAlerts(ctx context.Context, req *proto.ListActiveAlertsRequest) (*proto.ListActiveAlertsResponse, error) {
+	return &proto.ListActiveAlertsResponse{Alerts: s.alerts.Active()}, nil
+}
@@ -2,25 +2,85 @@ package database
 
 import (
 	"context"
+	"io"
 	"time"
 
 	"github.com/sirupsen/logrus"
-	"go.mongodb.org/mongo-driver/mongo"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
 
+	"github.com/brochadoluis/temperature-exercise/internal/faults"
+	"github.com/brochadoluis/temperature-exercise/internal/metrics"
+	"github.com/brochadoluis/temperature-exercise/internal/servicetoken"
+	"github.com/brochadoluis/temperature-exercise/internal/tracing"
+	"github.com/brochadoluis/temperature-exercise/internal/units"
 	"github.com/brochadoluis/temperature-exercise/proto"
 )
 
+// Policy restricts the writes to the services expected to make them, so
+// readings and keys cannot be injected by anyone on the network, and the
+// reading history, alert state and watchlist to tempctl. The other calls need
+// a token of any service, except for the health checks.
+var Policy = servicetoken.Policy{
+	"/temperature.Temperature/SaveTemperature":     {servicetoken.Scrapper},
+	"/temperature.Temperature/SaveForecast":        {servicetoken.Scrapper},
+	"/temperature.Temperature/CreateAPIKey":        {servicetoken.API},
+	"/temperature.Temperature/RevokeAPIKey":        {servicetoken.API},
+	"/temperature.Temperature/SetFaults":           {servicetoken.API},
+	"/temperature.Temperature/ListReadings":        {servicetoken.Tempctl},
+	"/temperature.Temperature/ListActiveAlerts":    {servicetoken.Tempctl},
+	"/temperature.Temperature/AddToWatchlist":      {servicetoken.Tempctl},
+	"/temperature.Temperature/RemoveFromWatchlist": {servicetoken.Tempctl},
+	"/temperature.Temperature/ListWatchlist":       {servicetoken.Tempctl},
+	healthpb.Health_Check_FullMethodName:           servicetoken.Public,
+	healthpb.Health_Watch_FullMethodName:           servicetoken.Public,
+}
+
 type Service struct {
-	db *mongo.Database
-	proto.TemperatureServer
+	proto.UnimplementedTemperatureServer
+	store        Store
+	alerts       *AlertBroker
+	writeTimeout time.Duration
+	faults       *faults.Injector
 }
 
-func NewService(db *mongo.Database) *Service {
+// NewService returns the database service. Faults of the Mongo target are
+// injected into the writes to the store, whichever store it is.
+func NewService(store Store, thresholds units.Thresholds, writeTimeout time.Duration, injector *faults.Injector) *Service {
+	if injector.Enabled() {
+		store = &faultyStore{Store: store, faults: injector}
+	}
 	return &Service{
-		db: db,
+		store:        store,
+		alerts:       NewAlertBroker(thresholds),
+		writeTimeout: writeTimeout,
+		faults:       injector,
 	}
 }
 
+// insert runs an insert into the collection and records the insert metrics.
+// The insert is bounded by the write timeout and by the deadline of the call.
+func (s *Service) insert(ctx context.Context, collection string, insert func(ctx context.Context) error) error {
+	ctx, span := tracing.Start(ctx, "database.insert "+collection)
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, s.writeTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err := insert(ctx)
+	metrics.ObserveInsert(collection, start, err)
+	return err
+}
+
+// insertReading stores the reading in the collection.
+func (s *Service) insertReading(ctx context.Context, collection string, r *reading) error {
+	return s.insert(ctx, collection, func(ctx context.Context) error {
+		return s.store.InsertReading(ctx, collection, r)
+	})
+}
+
 func (s *Service) SaveTemperature(ctx context.Context, req *proto.SaveTemperatureRequest) (*proto.SaveTemperatureResponse, error) {
 	// Retrieve the logger from the context
 	logger := logrus.WithContext(ctx)
@@ -31,13 +91,17 @@ func (s *Service) SaveTemperature(ctx context.Context, req *proto.SaveTemperatur
 		collectionName = "error"
 	}
 
-	// Save the temperature data to the appropriate collection
-	collection := s.db.Collection(collectionName)
-	data := map[string]interface{}{
-		"timestamp": time.Now(),
-		"request":   req,
+	// Save the temperature data to the appropriate collection. Readings
+	// retried by the scrapper keep the time they were taken
+	timestamp := time.Now()
+	if req.GetTakenAt() > 0 {
+		timestamp = time.Unix(0, req.GetTakenAt())
+	}
+	data := &reading{
+		Timestamp: timestamp,
+		Request:   req,
 	}
-	_, err := collection.InsertOne(ctx, data)
+	err := s.insertReading(ctx, collectionName, data)
 	if err != nil {
 		logger.Errorf("Failed to save temperature data to %s collection: %v", collectionName, err)
 		return nil, err
@@ -45,20 +109,95 @@ func (s *Service) SaveTemperature(ctx context.Context, req *proto.SaveTemperatur
 
 	// Save to alerts collection if Alert field is true
 	if req.GetAlert() {
-		alertsCollection := s.db.Collection("alert")
-		_, err := alertsCollection.InsertOne(ctx, data)
+		err := s.insertReading(ctx, "alert", data)
 		if err != nil {
 			logger.Errorf("Failed to save temperature data to alerts collection: %v", err)
 			return nil, err
 		}
 	}
 
+	// Track alert transitions for subscribers
+	if !req.GetError() {
+		s.alerts.Observe(req.GetLatitude(), req.GetLongitude(), req.GetTemperature(), req.GetAlert(), timestamp)
+	}
+
 	// Return the response
 	return &proto.SaveTemperatureResponse{
-		Latitude:    req.Latitude,
-		Longitude:   req.Longitude,
-		Temperature: req.Temperature,
-		Alert:       req.Alert,
-		Error:       req.Error,
+		Latitude:      req.Latitude,
+		Longitude:     req.Longitude,
+		Temperature:   req.Temperature,
+		Alert:         req.Alert,
+		Error:         req.Error,
+		WindSpeed:     req.WindSpeed,
+		WindDirection: req.WindDirection,
+		WeatherCode:   req.WeatherCode,
+		IsDay:         req.IsDay,
+		Condition:     req.Condition,
 	}, nil
 }
+
+func (s *Service) SubscribeAlerts(stream proto.Temperature_SubscribeAlertsServer) error {
+	ctx := stream.Context()
+	logger := logrus.WithContext(ctx).WithField("method", "SubscribeAlerts")
+
+	sub := s.alerts.Subscribe()
+	defer s.alerts.Unsubscribe(sub)
+
+	// Apply subscription changes as the client sends them
+	recvErr := make(chan error, 1)
+	go func() {
+		for {
+			req, err := stream.Recv()
+			if err != nil {
+				recvErr <- err
+				return
+			}
+			logger.Infof("Applying alert subscription: %v", req)
+			sub.Apply(req)
+		}
+	}()
+
+	for {
+		select {
+		case event := <-sub.Events():
+			if err := stream.Send(event); err != nil {
+				logger.Errorf("Failed to send alert event: %v", err)
+				return err
+			}
+		case <-sub.Dropped():
+			logger.Warn("Dropping slow alert subscriber")
+			return status.Error(codes.ResourceExhausted, "subscriber is too slow")
+		case err := <-recvErr:
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (s *Service) SaveForecast(ctx context.Context, req *proto.SaveForecastRequest) (*proto.SaveForecastResponse, error) {
+	logger := logrus.WithContext(ctx)
+
+	// Save the forecast snapshot so it can later be compared with observed readings
+	data := &forecastSnapshot{
+		Timestamp: time.Now(),
+		Latitude:  req.GetForecast().GetLatitude(),
+		Longitude: req.GetForecast().GetLongitude(),
+		Days:      req.GetDays(),
+		Forecast:  req.GetForecast(),
+	}
+	var id string
+	err := s.insert(ctx, "forecast", func(ctx context.Context) (err error) {
+		id, err = s.store.InsertForecast(ctx, data)
+		return err
+	})
+	if err != nil {
+		logger.Errorf("Failed to save forecast to forecast collection: %v", err)
+		return nil, err
+	}
+
+	return &proto.SaveForecastResponse{Id: id}, nil
+}
@@ -0,0 +1,99 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/brochadoluis/temperature-exercise/proto"
+)
+
+// ErrNotFound is returned by a Store when no document matches.
+var ErrNotFound = errors.New("not found")
+
+// ErrAlreadyExists is returned by a Store when a document with the same
+// unique key is stored already.
+var ErrAlreadyExists = errors.New("already exists")
+
+// Store persists the documents of the database service. MongoStore is used
+// in production, MemoryStore when running everything in one process.
+type Store interface {
+	// InsertReading adds a reading to the success, error or alert
+	// collection.
+	InsertReading(ctx context.Context, collection string, r *reading) error
+	// FindReadings returns the readings of the collection matching q,
+	// newest first.
+	FindReadings(ctx context.Context, collection string, q readingQuery) ([]reading, error)
+	// InsertForecast adds a forecast snapshot and returns its ID.
+	InsertForecast(ctx context.Context, f *forecastSnapshot) (string, error)
+	// InsertAPIKey adds an API key and sets its ID.
+	InsertAPIKey(ctx context.Context, k *apiKey) error
+	// RevokeAPIKey marks the key as revoked at the given time. It returns
+	// ErrNotFound if the key does not exist or is already revoked.
+	RevokeAPIKey(ctx context.Context, id primitive.ObjectID, at time.Time) error
+	// FindAPIKey returns the key with the hash that has not been revoked, or
+	// ErrNotFound.
+	FindAPIKey(ctx context.Context, hash string) (*apiKey, error)
+	// InsertWatch adds an entry to the watchlist. It returns
+	// ErrAlreadyExists if an entry has the same name.
+	InsertWatch(ctx context.Context, w *watch) error
+	// DeleteWatch removes the entry with the name from the watchlist, or
+	// returns ErrNotFound.
+	DeleteWatch(ctx context.Context, name string) error
+	// FindWatches returns the entries of the watchlist sorted by name.
+	FindWatches(ctx context.Context) ([]watch, error)
+}
+
+// reading is the document stored for every reading. The request is stored
+// as is, so its fields carry the lowercased Go names given by the default
+// codec.
+type reading struct {
+	Timestamp time.Time                     `bson:"timestamp"`
+	Request   *proto.SaveTemperatureRequest `bson:"request"`
+}
+
+func (r *reading) toProto() *proto.Reading {
+	return &proto.Reading{
+		Latitude:      r.Request.GetLatitude(),
+		Longitude:     r.Request.GetLongitude(),
+		Temperature:   r.Request.GetTemperature(),
+		Alert:         r.Request.GetAlert(),
+		Error:         r.Request.GetError(),
+		HttpCode:      r.Request.GetHttpCode(),
+		WindSpeed:     r.Request.GetWindSpeed(),
+		WindDirection: r.Request.GetWindDirection(),
+		WeatherCode:   r.Request.GetWeatherCode(),
+		IsDay:         r.Request.GetIsDay(),
+		Condition:     r.Request.GetCondition(),
+		Timestamp:     r.Timestamp.Unix(),
+	}
+}
+
+// readingQuery selects readings. Locations match within locationTolerance.
+type readingQuery struct {
+	Latitude     float64
+	Longitude    float64
+	AllLocations bool
+	// Since is the time of the oldest reading, zero for no bound.
+	Since time.Time
+	Limit int64
+}
+
+func (q readingQuery) matches(r *reading) bool {
+	if !q.AllLocations && !(location{latitude: q.Latitude, longitude: q.Longitude}).matches(r.Request.GetLatitude(), r.Request.GetLongitude()) {
+		return false
+	}
+	return q.Since.IsZero() || !r.Timestamp.Before(q.Since)
+}
+
+// forecastSnapshot is the document stored for a forecast, so it can later be
+// compared with the observed readings.
+type forecastSnapshot struct {
+	Timestamp time.Time                   `bson:"timestamp"`
+	Latitude  float64                     `bson:"latitude"`
+	Longitude float64                     `bson:"longitude"`
+	Days      int32                       `bson:"days"`
+	Forecast  *proto.ListForecastResponse `bson:"forecast"`
+}
@@ -0,0 +1,118 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/brochadoluis/temperature-exercise/proto"
+)
+
+// WatchlistCollection holds the locations engineers keep an eye on with
+// tempctl.
+const WatchlistCollection = "watchlist"
+
+// watch is the document stored for a watchlist entry. Names are unique.
+type watch struct {
+	Name      string    `bson:"name"`
+	Latitude  float64   `bson:"latitude"`
+	Longitude float64   `bson:"longitude"`
+	CreatedAt time.Time `bson:"created_at"`
+}
+
+func (w *watch) toProto() *proto.WatchlistEntry {
+	return &proto.WatchlistEntry{
+		Name:      w.Name,
+		Latitude:  w.Latitude,
+		Longitude: w.Longitude,
+		CreatedAt: w.CreatedAt.Unix(),
+	}
+}
+
+func (s *Service) AddToWatchlist(ctx context.Context, req *proto.AddToWatchlistRequest) (*proto.WatchlistEntry, error) {
+	logger := logrus.WithContext(ctx)
+
+	if req.GetName() == "" {
+		return nil, status.Error(codes.InvalidArgument, "name is required")
+	}
+	if req.GetLatitude() < -90 || req.GetLatitude() > 90 || req.GetLongitude() < -180 || req.GetLongitude() > 180 {
+		return nil, status.Error(codes.InvalidArgument, "latitude must be between -90 and 90 and longitude between -180 and 180")
+	}
+
+	doc := &watch{
+		Name:      req.GetName(),
+		Latitude:  req.GetLatitude(),
+		Longitude: req.GetLongitude(),
+		// Mongo stores milliseconds, truncate so the response matches the document
+		CreatedAt: time.Now().UTC().Truncate(time.Millisecond),
+	}
+	err := s.insert(ctx, WatchlistCollection, func(ctx context.Context) error {
+		return s.store.InsertWatch(ctx, doc)
+	})
+	if errors.Is(err, ErrAlreadyExists) {
+		return nil, status.Errorf(codes.AlreadyExists, "%s is already on the watchlist", doc.Name)
+	}
+	if err != nil {
+		logger.Errorf("Failed to save watchlist entry to %s collection: %v", WatchlistCollection, err)
+		return nil, err
+	}
+
+	logger.Infof("Added %s at %v,%v to the watchlist", doc.Name, doc.Latitude, doc.Longitude)
+
+	return doc.toProto(), nil
+}
+
+func (s *Service) RemoveFromWatchlist(ctx context.Context, req *proto.RemoveFromWatchlistRequest) (*proto.RemoveFromWatchlistResponse, error) {
+	logger := logrus.WithContext(ctx)
+
+	ctx, cancel := context.WithTimeout(ctx, s.writeTimeout)
+	defer cancel()
+
+	err := s.store.DeleteWatch(ctx, req.GetName())
+	if errors.Is(err, ErrNotFound) {
+		return nil, status.Errorf(codes.NotFound, "%s is not on the watchlist", req.GetName())
+	}
+	if err != nil {
+		logger.Errorf("Failed to remove %s from the watchlist: %v", req.GetName(), err)
+		return nil, err
+	}
+
+	logger.Infof("Removed %s from the watchlist", req.GetName())
+
+	return &proto.RemoveFromWatchlistResponse{}, nil
+}
+
+// ListWatchlist returns the watchlist with the latest successful reading of
+// every location, matched within the same tolerance as ListReadings.
+func (s *Service) ListWatchlist(ctx context.Context, req *proto.ListWatchlistRequest) (*proto.ListWatchlistResponse, error) {
+	logger := logrus.WithContext(ctx)
+
+	docs, err := s.store.FindWatches(ctx)
+	if err != nil {
+		logger.Errorf("Failed to list the watchlist: %v", err)
+		return nil, err
+	}
+
+	resp := &proto.ListWatchlistResponse{Entries: make([]*proto.WatchlistEntry, 0, len(docs))}
+	for i := range docs {
+		entry := docs[i].toProto()
+		latest, err := s.store.FindReadings(ctx, "success", readingQuery{
+			Latitude:  docs[i].Latitude,
+			Longitude: docs[i].Longitude,
+			Limit:     1,
+		})
+		if err != nil {
+			logger.Errorf("Failed to find the latest reading of %s: %v", docs[i].Name, err)
+			return nil, err
+		}
+		if len(latest) > 0 {
+			entry.Latest = latest[0].toProto()
+		}
+		resp.Entries = append(resp.Entries, entry)
+	}
+	return resp, nil
+}
@@ -0,0 +1,91 @@
+package deadline
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Gin bounds the context of every request handled by the route to timeout.
+// The deadline travels with the context to the gRPC calls made by the
+// handler, so downstream services stop working on requests nobody waits for.
+func Gin(timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// Budget returns how long a step may take: share of the time left before the
+// deadline of ctx, capped at max. Without a deadline the step gets max.
+func Budget(ctx context.Context, max time.Duration, share float64) time.Duration {
+	dl, ok := ctx.Deadline()
+	if !ok {
+		return max
+	}
+	budget := time.Duration(float64(time.Until(dl)) * share)
+	if budget > max {
+		return max
+	}
+	return budget
+}
+
+// WithBudget returns a copy of ctx bounded by Budget, leaving the rest of the
+// time left to the steps that follow.
+func WithBudget(ctx context.Context, max time.Duration, share float64) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, Budget(ctx, max, share))
+}
+
+// Exceeded reports whether err was caused by a deadline, either locally or
+// in a downstream gRPC service.
+func Exceeded(err error) bool {
+	return errors.Is(err, context.DeadlineExceeded) || status.Code(err) == codes.DeadlineExceeded
+}
+
+// Status converts context errors into the matching gRPC status so callers can
+// tell a timeout apart from a failure. Other errors are returned unchanged.
+func Status(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	}
+	return err
+}
+
+// UnaryClientInterceptor bounds outgoing unary calls without a deadline to
+// timeout. Streams are left alone, they last as long as their caller wants.
+func UnaryClientInterceptor(timeout time.Duration) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if _, ok := ctx.Deadline(); !ok {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// UnaryServerInterceptor reports calls that ran out of time as
+// DeadlineExceeded instead of Unknown.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		resp, err := handler(ctx, req)
+		return resp, Status(err)
+	}
+}
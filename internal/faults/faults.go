@@ -0,0 +1,382 @@
+// Package faults injects latency and failures into the calls between the
+// services and to their dependencies, to verify how the system behaves when
+// things break. Injection is disabled unless configured, and the faults are
+// changed at runtime through the SetFaults RPC.
+package faults
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/brochadoluis/temperature-exercise/internal/metrics"
+	"github.com/brochadoluis/temperature-exercise/proto"
+)
+
+// Targets faults are injected into.
+const (
+	// OpenMeteo is the requests of the scrapper to Open-Meteo.
+	OpenMeteo = "open-meteo"
+	// ScrapperDatabase is the calls of the scrapper to the database service.
+	ScrapperDatabase = "scrapper-database"
+	// Database is the calls handled by the database service.
+	Database = "database"
+	// Mongo is the writes of the database service to its store.
+	Mongo = "mongo"
+)
+
+// Targets of each service.
+var (
+	ScrapperTargets = []string{OpenMeteo, ScrapperDatabase}
+	DatabaseTargets = []string{Database, Mongo}
+)
+
+var (
+	// ErrInjected is the failure of targets without a gRPC code or HTTP
+	// status.
+	ErrInjected = errors.New("injected fault")
+	// ErrDisabled is returned when faults are set while injection is
+	// disabled.
+	ErrDisabled = errors.New("fault injection is disabled")
+)
+
+// setFaultsMethods are never failed, so faults can always be cleared.
+var setFaultsMethods = map[string]bool{
+	"/temperature.Temperature/SetFaults":  true,
+	"/temperature.Temperature/ListFaults": true,
+}
+
+// Fault describes what happens to the calls of a target.
+type Fault struct {
+	// Latency is added to every call.
+	Latency time.Duration
+	// ErrorRate is the share of the calls that fail, between 0 and 1.
+	ErrorRate float64
+	// Code is the gRPC code of the failures of gRPC targets.
+	Code codes.Code
+	// HTTPStatus is the status of the failures of OpenMeteo. 0 fails the
+	// request without a response.
+	HTTPStatus int
+}
+
+// Injector holds the faults of the targets of a service.
+type Injector struct {
+	enabled bool
+	targets map[string]bool
+	random  func() float64
+
+	mu     sync.RWMutex
+	faults map[string]Fault
+}
+
+// NewInjector returns an injector for the targets. A disabled injector never
+// injects anything and rejects faults.
+func NewInjector(enabled bool, targets ...string) *Injector {
+	known := make(map[string]bool, len(targets))
+	for _, target := range targets {
+		known[target] = true
+	}
+	return &Injector{
+		enabled: enabled,
+		targets: known,
+		random:  rand.Float64,
+		faults:  make(map[string]Fault),
+	}
+}
+
+// Enabled reports whether faults can be injected.
+func (i *Injector) Enabled() bool {
+	return i != nil && i.enabled
+}
+
+// Set replaces the faults of every target, nil clears them.
+func (i *Injector) Set(faults map[string]Fault) error {
+	if !i.Enabled() {
+		return ErrDisabled
+	}
+	for target, f := range faults {
+		if err := i.validate(target, f); err != nil {
+			return err
+		}
+	}
+
+	replaced := make(map[string]Fault, len(faults))
+	for target, f := range faults {
+		replaced[target] = f
+	}
+
+	i.mu.Lock()
+	i.faults = replaced
+	i.mu.Unlock()
+
+	log.Warnf("Fault injection set to %v", replaced)
+	return nil
+}
+
+func (i *Injector) validate(target string, f Fault) error {
+	if !i.targets[target] {
+		return errors.Errorf("unknown fault target %q", target)
+	}
+	return Validate(target, f)
+}
+
+// Validate checks that the fault makes sense for its target, so callers can
+// reject faults before sending them to a service.
+func Validate(target string, f Fault) error {
+	if f.Latency < 0 {
+		return errors.Errorf("latency of %s cannot be negative", target)
+	}
+	if f.ErrorRate < 0 || f.ErrorRate > 1 {
+		return errors.Errorf("error rate of %s must be between 0 and 1", target)
+	}
+	if f.HTTPStatus != 0 && target != OpenMeteo {
+		return errors.Errorf("%s does not fail with HTTP statuses", target)
+	}
+	if f.HTTPStatus != 0 && (f.HTTPStatus < 400 || f.HTTPStatus > 599) {
+		return errors.Errorf("HTTP status of %s must be between 400 and 599", target)
+	}
+	if f.Code != codes.OK && (target == OpenMeteo || target == Mongo) {
+		return errors.Errorf("%s does not fail with gRPC codes", target)
+	}
+	return nil
+}
+
+// Faults returns the faults by target.
+func (i *Injector) Faults() map[string]Fault {
+	if !i.Enabled() {
+		return nil
+	}
+
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	faults := make(map[string]Fault, len(i.faults))
+	for target, f := range i.faults {
+		faults[target] = f
+	}
+	return faults
+}
+
+// inject waits for the latency of the target, then reports whether the call
+// fails. It returns the error of ctx if ctx is done while waiting.
+func (i *Injector) inject(ctx context.Context, target string) (Fault, bool, error) {
+	if !i.Enabled() {
+		return Fault{}, false, nil
+	}
+
+	i.mu.RLock()
+	f, ok := i.faults[target]
+	i.mu.RUnlock()
+	if !ok {
+		return Fault{}, false, nil
+	}
+
+	if f.Latency > 0 {
+		timer := time.NewTimer(f.Latency)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return f, false, ctx.Err()
+		}
+	}
+
+	if f.ErrorRate <= 0 || i.random() >= f.ErrorRate {
+		return f, false, nil
+	}
+	metrics.ObserveFault(target)
+	return f, true, nil
+}
+
+// Error injects the fault of the target into a call. It returns the error the
+// call fails with, a gRPC status if the fault has a code, or nil.
+func (i *Injector) Error(ctx context.Context, target string) error {
+	f, fail, err := i.inject(ctx, target)
+	if err != nil || !fail {
+		return err
+	}
+	if f.Code != codes.OK {
+		return status.Error(f.Code, ErrInjected.Error())
+	}
+	return ErrInjected
+}
+
+// UnaryServerInterceptor injects the fault of the target into the calls of
+// the Temperature service. Health checks and the fault RPCs are left alone.
+func (i *Injector) UnaryServerInterceptor(target string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if !i.Enabled() || setFaultsMethods[info.FullMethod] || !strings.HasPrefix(info.FullMethod, "/temperature.Temperature/") {
+			return handler(ctx, req)
+		}
+		if err := i.Error(ctx, target); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// UnaryClientInterceptor injects the fault of the target into outgoing calls,
+// before they are sent.
+func (i *Injector) UnaryClientInterceptor(target string) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if err := i.Error(ctx, target); err != nil {
+			return err
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// Transport injects the fault of the target into the requests of next. Failed
+// requests get the HTTP status of the fault with an Open-Meteo error body, or
+// no response at all.
+func (i *Injector) Transport(target string, next http.RoundTripper) http.RoundTripper {
+	if !i.Enabled() {
+		return next
+	}
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		f, fail, err := i.inject(req.Context(), target)
+		if err != nil {
+			return nil, err
+		}
+		if !fail {
+			return next.RoundTrip(req)
+		}
+		if f.HTTPStatus == 0 {
+			return nil, ErrInjected
+		}
+
+		body := fmt.Sprintf(`{"error":true,"reason":%q}`, ErrInjected.Error())
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", f.HTTPStatus, http.StatusText(f.HTTPStatus)),
+			StatusCode:    f.HTTPStatus,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        http.Header{"Content-Type": []string{"application/json"}},
+			Body:          io.NopCloser(strings.NewReader(body)),
+			ContentLength: int64(len(body)),
+			Request:       req,
+		}, nil
+	})
+}
+
+// SetFaults serves the SetFaults RPC of the service.
+func (i *Injector) SetFaults(ctx context.Context, req *proto.SetFaultsRequest) (*proto.SetFaultsResponse, error) {
+	faults, err := FromProto(req.GetFaults())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	err = i.Set(faults)
+	if err == ErrDisabled {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return &proto.SetFaultsResponse{Faults: ToProto(i.Faults())}, nil
+}
+
+// ListFaults serves the ListFaults RPC of the service.
+func (i *Injector) ListFaults(ctx context.Context, req *proto.ListFaultsRequest) (*proto.ListFaultsResponse, error) {
+	return &proto.ListFaultsResponse{Faults: ToProto(i.Faults())}, nil
+}
+
+// FromProto converts faults from their messages. Failures of gRPC targets
+// without a code are Unavailable.
+func FromProto(messages []*proto.Fault) (map[string]Fault, error) {
+	faults := make(map[string]Fault, len(messages))
+	for _, m := range messages {
+		if _, ok := faults[m.GetTarget()]; ok {
+			return nil, errors.Errorf("duplicate fault target %q", m.GetTarget())
+		}
+
+		f := Fault{
+			Latency:    time.Duration(m.GetLatencyMs()) * time.Millisecond,
+			ErrorRate:  m.GetErrorRate(),
+			HTTPStatus: int(m.GetHttpStatus()),
+		}
+		switch {
+		case m.GetCode() != "":
+			code, err := ParseCode(m.GetCode())
+			if err != nil {
+				return nil, err
+			}
+			f.Code = code
+		case m.GetTarget() == ScrapperDatabase || m.GetTarget() == Database:
+			f.Code = codes.Unavailable
+		}
+		faults[m.GetTarget()] = f
+	}
+	return faults, nil
+}
+
+// ToProto converts faults to their messages, sorted by target.
+func ToProto(faults map[string]Fault) []*proto.Fault {
+	messages := make([]*proto.Fault, 0, len(faults))
+	for target, f := range faults {
+		m := &proto.Fault{
+			Target:     target,
+			LatencyMs:  f.Latency.Milliseconds(),
+			ErrorRate:  f.ErrorRate,
+			HttpStatus: int32(f.HTTPStatus),
+		}
+		if f.Code != codes.OK {
+			m.Code = codeNames[f.Code]
+		}
+		messages = append(messages, m)
+	}
+	sort.Slice(messages, func(a, b int) bool {
+		return messages[a].GetTarget() < messages[b].GetTarget()
+	})
+	return messages
+}
+
+// codeNames are the gRPC codes a call can fail with, as named by the gRPC
+// specification.
+var codeNames = map[codes.Code]string{
+	codes.Canceled:           "CANCELLED",
+	codes.Unknown:            "UNKNOWN",
+	codes.InvalidArgument:    "INVALID_ARGUMENT",
+	codes.DeadlineExceeded:   "DEADLINE_EXCEEDED",
+	codes.NotFound:           "NOT_FOUND",
+	codes.AlreadyExists:      "ALREADY_EXISTS",
+	codes.PermissionDenied:   "PERMISSION_DENIED",
+	codes.ResourceExhausted:  "RESOURCE_EXHAUSTED",
+	codes.FailedPrecondition: "FAILED_PRECONDITION",
+	codes.Aborted:            "ABORTED",
+	codes.OutOfRange:         "OUT_OF_RANGE",
+	codes.Unimplemented:      "UNIMPLEMENTED",
+	codes.Internal:           "INTERNAL",
+	codes.Unavailable:        "UNAVAILABLE",
+	codes.DataLoss:           "DATA_LOSS",
+	codes.Unauthenticated:    "UNAUTHENTICATED",
+}
+
+// ParseCode returns the gRPC code named name, e.g. UNAVAILABLE.
+func ParseCode(name string) (codes.Code, error) {
+	for code, n := range codeNames {
+		if strings.EqualFold(n, name) {
+			return code, nil
+		}
+	}
+	return codes.OK, errors.Errorf("unknown gRPC code %q", name)
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
@@ -0,0 +1,79 @@
+package faults
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestErrorRate(t *testing.T) {
+	i := NewInjector(true, DatabaseTargets...)
+	if err := i.Set(map[string]Fault{Database: {ErrorRate: 0.25, Code: codes.Aborted}}); err != nil {
+		t.Fatalf("Failed to set faults: %v", err)
+	}
+
+	tests := []struct {
+		random float64
+		fail   bool
+	}{
+		{0, true},
+		{0.24, true},
+		{0.25, false},
+		{0.99, false},
+	}
+	for _, tt := range tests {
+		i.random = func() float64 { return tt.random }
+		err := i.Error(context.Background(), Database)
+		if fail := err != nil; fail != tt.fail {
+			t.Errorf("Draw %v failed %v, want %v", tt.random, fail, tt.fail)
+		}
+		if err != nil && status.Code(err) != codes.Aborted {
+			t.Errorf("Draw %v failed with %v, want Aborted", tt.random, err)
+		}
+	}
+
+	// Targets without a fault never fail
+	if err := i.Error(context.Background(), Mongo); err != nil {
+		t.Errorf("Mongo failed without a fault: %v", err)
+	}
+}
+
+func TestTransportWithoutResponse(t *testing.T) {
+	i := NewInjector(true, ScrapperTargets...)
+	if err := i.Set(map[string]Fault{OpenMeteo: {ErrorRate: 1}}); err != nil {
+		t.Fatalf("Failed to set faults: %v", err)
+	}
+
+	called := false
+	next := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		called = true
+		return nil, errors.New("unexpected call")
+	})
+	req, _ := http.NewRequest(http.MethodGet, "http://open-meteo/v1/forecast", nil)
+	if _, err := i.Transport(OpenMeteo, next).RoundTrip(req); errors.Cause(err) != ErrInjected {
+		t.Errorf("Request failed with %v, want %v", err, ErrInjected)
+	}
+	if called {
+		t.Error("The failed request reached the upstream")
+	}
+}
+
+func TestDisabled(t *testing.T) {
+	i := NewInjector(false, DatabaseTargets...)
+
+	if err := i.Set(map[string]Fault{Mongo: {ErrorRate: 1}}); err != ErrDisabled {
+		t.Errorf("Setting faults returned %v, want %v", err, ErrDisabled)
+	}
+	if err := i.Error(context.Background(), Mongo); err != nil {
+		t.Errorf("Disabled injector failed a call: %v", err)
+	}
+
+	var none *Injector
+	if none.Enabled() {
+		t.Error("Nil injector is enabled")
+	}
+}
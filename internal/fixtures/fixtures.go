@@ -0,0 +1,190 @@
+// Package fixtures records the responses of an upstream HTTP API to golden
+// files and serves them back, so parsing issues can be reproduced without
+// calling the live API.
+package fixtures
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// Modes of Transport.
+const (
+	ModeNone   = "none"
+	ModeRecord = "record"
+	ModeReplay = "replay"
+)
+
+// Fixture is the content of a golden file: a request and the response it got.
+type Fixture struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+type Request struct {
+	Method string `json:"method"`
+	// URL is the path and the sorted query of the request. The host is left
+	// out, so fixtures replay against any base URL.
+	URL string `json:"url"`
+}
+
+type Response struct {
+	StatusCode  int    `json:"status_code"`
+	ContentType string `json:"content_type,omitempty"`
+	// Body holds JSON bodies, indented to keep the files readable, and
+	// BodyText any other body.
+	Body     json.RawMessage `json:"body,omitempty"`
+	BodyText string          `json:"body_text,omitempty"`
+}
+
+// Transport wraps next for the mode: it records the responses of next to dir,
+// replays them from dir without calling next, or returns next unchanged.
+func Transport(mode, dir string, next http.RoundTripper) http.RoundTripper {
+	switch mode {
+	case ModeRecord:
+		return Record(dir, next)
+	case ModeReplay:
+		return Replay(dir)
+	default:
+		return next
+	}
+}
+
+// Record returns a transport that saves every response of next to a golden
+// file in dir. A later response to the same request replaces the file.
+// Failing to save is logged, the response is returned either way.
+func Record(dir string, next http.RoundTripper) http.RoundTripper {
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		resp, err := next.RoundTrip(req)
+		if err != nil {
+			return nil, err
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read response body")
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+
+		f := Fixture{
+			Request: request(req),
+			Response: Response{
+				StatusCode:  resp.StatusCode,
+				ContentType: resp.Header.Get("Content-Type"),
+			},
+		}
+		if json.Valid(body) {
+			f.Response.Body = body
+		} else {
+			f.Response.BodyText = string(body)
+		}
+
+		if err := save(dir, &f); err != nil {
+			log.WithContext(req.Context()).Errorf("Failed to record fixture for %s %s: %v", f.Request.Method, f.Request.URL, err)
+		}
+		return resp, nil
+	})
+}
+
+// Replay returns a transport that answers from the golden files in dir and
+// never calls the network. Requests without a golden file fail.
+func Replay(dir string) http.RoundTripper {
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		r := request(req)
+		data, err := os.ReadFile(filepath.Join(dir, r.fileName()))
+		if os.IsNotExist(err) {
+			return nil, errors.Errorf("no fixture for %s %s in %s", r.Method, r.URL, dir)
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read fixture")
+		}
+
+		var f Fixture
+		if err := json.Unmarshal(data, &f); err != nil {
+			return nil, errors.Wrapf(err, "invalid fixture for %s %s", r.Method, r.URL)
+		}
+
+		body := []byte(f.Response.BodyText)
+		if len(f.Response.Body) > 0 {
+			// The file indents JSON bodies, serve them compact like the
+			// upstream did
+			var compact bytes.Buffer
+			if err := json.Compact(&compact, f.Response.Body); err != nil {
+				return nil, errors.Wrapf(err, "invalid fixture body for %s %s", r.Method, r.URL)
+			}
+			body = compact.Bytes()
+		}
+		header := make(http.Header)
+		if f.Response.ContentType != "" {
+			header.Set("Content-Type", f.Response.ContentType)
+		}
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", f.Response.StatusCode, http.StatusText(f.Response.StatusCode)),
+			StatusCode:    f.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          io.NopCloser(bytes.NewReader(body)),
+			ContentLength: int64(len(body)),
+			Request:       req,
+		}, nil
+	})
+}
+
+func request(req *http.Request) Request {
+	u := req.URL.Path
+	if query := req.URL.Query(); len(query) > 0 {
+		// Encode sorts by key, so the order of the parameters does not matter
+		u += "?" + query.Encode()
+	}
+	return Request{
+		Method: req.Method,
+		URL:    u,
+	}
+}
+
+// fileName names the golden file after the path, for browsing, and a hash of
+// the whole request, so requests differing only in their query get their own
+// files.
+func (r Request) fileName() string {
+	sum := sha256.Sum256([]byte(r.Method + " " + r.URL))
+	path, _, _ := strings.Cut(r.URL, "?")
+	name := strings.ReplaceAll(strings.Trim(path, "/"), "/", "_")
+	if name == "" {
+		name = "root"
+	}
+	return fmt.Sprintf("%s-%s-%s.json", strings.ToLower(r.Method), name, hex.EncodeToString(sum[:8]))
+}
+
+func save(dir string, f *Fixture) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	var data bytes.Buffer
+	encoder := json.NewEncoder(&data)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(f); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, f.Request.fileName()), data.Bytes(), 0o644)
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
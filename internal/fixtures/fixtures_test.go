@@ -0,0 +1,86 @@
+package fixtures
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("fail") != "" {
+			w.Header().Set("Content-Type", "text/plain")
+			w.WriteHeader(http.StatusBadGateway)
+			io.WriteString(w, "bad gateway")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"latitude":38.7,"current_weather":{"temperature":21.5}}`)
+	}))
+	dir := t.TempDir()
+
+	recorder := &http.Client{Transport: Record(dir, http.DefaultTransport)}
+	recorded := map[string]string{
+		"/v1/forecast?latitude=38.7&longitude=-9.1": `{"latitude":38.7,"current_weather":{"temperature":21.5}}`,
+		"/v1/forecast?fail=true":                    "bad gateway",
+	}
+	for path, want := range recorded {
+		resp, err := recorder.Get(upstream.URL + path)
+		if err != nil {
+			t.Fatalf("Failed to record %s: %v", path, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if string(body) != want {
+			t.Errorf("Recording %s answered %q, want %q", path, body, want)
+		}
+	}
+
+	files, err := os.ReadDir(dir)
+	if err != nil || len(files) != len(recorded) {
+		t.Fatalf("Recorded %d files, want %d: %v", len(files), len(recorded), err)
+	}
+
+	// Replay never reaches the upstream, and does not depend on its host or
+	// on the order of the query
+	upstream.Close()
+	replayer := &http.Client{Transport: Replay(dir)}
+
+	tests := []struct {
+		url         string
+		status      int
+		contentType string
+		body        string
+	}{
+		{"http://elsewhere/v1/forecast?longitude=-9.1&latitude=38.7", http.StatusOK, "application/json", recorded["/v1/forecast?latitude=38.7&longitude=-9.1"]},
+		{"http://elsewhere/v1/forecast?fail=true", http.StatusBadGateway, "text/plain", "bad gateway"},
+	}
+	for _, tt := range tests {
+		resp, err := replayer.Get(tt.url)
+		if err != nil {
+			t.Fatalf("Failed to replay %s: %v", tt.url, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if resp.StatusCode != tt.status {
+			t.Errorf("Replay of %s answered %d, want %d", tt.url, resp.StatusCode, tt.status)
+		}
+		if got := resp.Header.Get("Content-Type"); got != tt.contentType {
+			t.Errorf("Replay of %s has content type %q, want %q", tt.url, got, tt.contentType)
+		}
+		if string(body) != tt.body {
+			t.Errorf("Replay of %s answered %q, want %q", tt.url, body, tt.body)
+		}
+	}
+}
+
+func TestReplayMissingFixture(t *testing.T) {
+	client := &http.Client{Transport: Replay(t.TempDir())}
+
+	if _, err := client.Get("http://elsewhere/v1/forecast?latitude=1&longitude=2"); err == nil {
+		t.Error("Replay without a fixture succeeded")
+	}
+}
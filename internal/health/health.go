@@ -0,0 +1,105 @@
+package health
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// Service is the name of the Temperature service as reported by the
+// grpc.health.v1 protocol.
+const Service = "temperature.Temperature"
+
+// checkTimeout bounds a single dependency check.
+const checkTimeout = 2 * time.Second
+
+// Check reports whether a dependency is usable.
+type Check func(ctx context.Context) error
+
+// Run evaluates the checks every interval and updates the status of the
+// server and of Service until ctx is done. The first evaluation happens
+// immediately.
+func Run(ctx context.Context, server *health.Server, interval time.Duration, checks map[string]Check) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		status := healthpb.HealthCheckResponse_SERVING
+		for name, err := range Evaluate(ctx, checks) {
+			if err != nil {
+				log.WithContext(ctx).Warnf("Health check %s failed: %v", name, err)
+				status = healthpb.HealthCheckResponse_NOT_SERVING
+			}
+		}
+
+		server.SetServingStatus("", status)
+		server.SetServingStatus(Service, status)
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Evaluate runs every check concurrently and returns their results by name.
+func Evaluate(ctx context.Context, checks map[string]Check) map[string]error {
+	type result struct {
+		name string
+		err  error
+	}
+
+	results := make(chan result, len(checks))
+	for name, check := range checks {
+		go func(name string, check Check) {
+			checkCtx, cancel := context.WithTimeout(ctx, checkTimeout)
+			defer cancel()
+			results <- result{name: name, err: check(checkCtx)}
+		}(name, check)
+	}
+
+	errs := make(map[string]error, len(checks))
+	for range checks {
+		r := <-results
+		errs[r.name] = r.err
+	}
+	return errs
+}
+
+// GRPC checks a gRPC server through the grpc.health.v1 protocol.
+func GRPC(conn *grpc.ClientConn) Check {
+	client := healthpb.NewHealthClient(conn)
+	return func(ctx context.Context) error {
+		resp, err := client.Check(ctx, &healthpb.HealthCheckRequest{Service: Service})
+		if err != nil {
+			return err
+		}
+		if resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+			return errors.Errorf("status is %s", resp.GetStatus())
+		}
+		return nil
+	}
+}
+
+// HTTP checks that an HTTP endpoint is reachable. Any response counts, only
+// transport errors fail the check.
+func HTTP(client *http.Client, url string) Check {
+	return func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		return resp.Body.Close()
+	}
+}
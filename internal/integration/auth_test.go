@@ -0,0 +1,116 @@
+package integration
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/brochadoluis/temperature-exercise/internal/api"
+	"github.com/brochadoluis/temperature-exercise/internal/config"
+)
+
+func TestAdminToken(t *testing.T) {
+	h := newHarness(t)
+
+	tests := []struct {
+		name          string
+		authorization string
+		want          int
+	}{
+		{"bearer token", "Bearer " + adminToken, http.StatusOK},
+		{"bare token", adminToken, http.StatusUnauthorized},
+		{"wrong token", "Bearer wrong", http.StatusUnauthorized},
+		{"other scheme", "Basic " + adminToken, http.StatusUnauthorized},
+		{"missing", "", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/admin/faults", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			rec := httptest.NewRecorder()
+			h.router.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("GET /admin/faults answered %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+		})
+	}
+}
+
+func TestAlertStreamAPIKeyQuery(t *testing.T) {
+	h := newHarness(t, withConfig(func(cfg *config.Config) {
+		cfg.Auth.Enabled = true
+	}))
+
+	rec := h.admin(http.MethodPost, "/admin/keys", api.CreateKeyRequest{Name: "browser"})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("POST /admin/keys answered %d: %s", rec.Code, rec.Body)
+	}
+	var key api.KeyResponse
+	decode(t, rec.Body.Bytes(), &key)
+
+	server := httptest.NewServer(h.router)
+	t.Cleanup(server.Close)
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/alerts/ws"
+
+	conn, _, err := websocket.DefaultDialer.Dial(url+"?api_key="+key.Key, nil)
+	if err != nil {
+		t.Fatalf("Failed to open the alert stream with the key in the query: %v", err)
+	}
+	conn.Close()
+
+	_, resp, err := websocket.DefaultDialer.Dial(url, nil)
+	if err == nil || resp == nil || resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Opening the alert stream without a key failed with %v, want 401", err)
+	}
+
+	// Only the alert stream takes the key from the query
+	if rec := h.get("/v2/temperature?latitude=38.7&longitude=-9.1&api_key=" + key.Key); rec.Code != http.StatusUnauthorized {
+		t.Errorf("GET /v2/temperature with the key in the query answered %d, want 401", rec.Code)
+	}
+}
+
+func TestWatchChargesQuota(t *testing.T) {
+	h := newHarness(t, withConfig(func(cfg *config.Config) {
+		cfg.Auth.Enabled = true
+	}))
+
+	quota := int32(2)
+	rec := h.admin(http.MethodPost, "/admin/keys", api.CreateKeyRequest{Name: "watcher", DailyQuota: &quota})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("POST /admin/keys answered %d: %s", rec.Code, rec.Body)
+	}
+	var key api.KeyResponse
+	decode(t, rec.Body.Bytes(), &key)
+
+	// The request pays for the first reading and the second one uses up the
+	// quota, so the stream ends at the third
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	req := httptest.NewRequest(http.MethodGet, "/api/temperature/watch?latitude=38.7&longitude=-9.1&interval_seconds=1", nil).WithContext(ctx)
+	req.Header.Set(api.APIKeyHeader, key.Key)
+	rec = httptest.NewRecorder()
+	h.router.ServeHTTP(rec, req)
+	if ctx.Err() != nil {
+		t.Fatal("The watch stream outlived the quota of its key")
+	}
+
+	lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+	if len(lines) != 3 || !strings.Contains(lines[2], "daily quota exceeded") {
+		t.Errorf("Watch streamed %q, want two readings and the quota error", lines)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/v2/temperature?latitude=38.7&longitude=-9.1", nil)
+	req.Header.Set(api.APIKeyHeader, key.Key)
+	rec = httptest.NewRecorder()
+	h.router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusTooManyRequests {
+		t.Errorf("GET /v2/temperature after the watch answered %d, want 429", rec.Code)
+	}
+}
@@ -0,0 +1,6 @@
+// Package integration holds the end-to-end tests of the system. The tests
+// run the API, scrapper and database service in the test process, wired over
+// in-memory gRPC connections, with Open-Meteo replaced by a local stub and
+// the database service keeping its documents in memory. No Docker or
+// network access is needed.
+package integration
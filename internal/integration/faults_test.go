@@ -0,0 +1,136 @@
+package integration
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/brochadoluis/temperature-exercise/internal/api"
+	"github.com/brochadoluis/temperature-exercise/internal/config"
+	"github.com/brochadoluis/temperature-exercise/internal/faults"
+)
+
+func TestFaultsDisabled(t *testing.T) {
+	h := newHarness(t, withConfig(func(cfg *config.Config) {
+		cfg.Faults.Enabled = false
+	}))
+
+	rec := h.admin(http.MethodPut, "/admin/faults", api.SetFaultsRequest{Faults: []api.Fault{{Target: faults.Mongo, ErrorRate: 1}}})
+	if rec.Code != http.StatusConflict {
+		t.Errorf("PUT /admin/faults answered %d, want %d: %s", rec.Code, http.StatusConflict, rec.Body)
+	}
+
+	var resp api.TemperatureEnvelope
+	h.getJSON(temperaturePath, http.StatusOK, &resp)
+}
+
+func TestOpenMeteoFault(t *testing.T) {
+	h := newHarness(t)
+	h.setFaults(api.Fault{Target: faults.OpenMeteo, ErrorRate: 1, HTTPStatus: http.StatusBadGateway})
+
+	var failed api.ErrorResponse
+	h.getJSON(temperaturePath, http.StatusInternalServerError, &failed)
+
+	if n := h.meteo.requests(); n != 0 {
+		t.Errorf("Open-Meteo was called %d times, want none", n)
+	}
+	errs := h.readings(latitude, longitude, true)
+	if len(errs) != 1 || errs[0].GetHttpCode() != http.StatusBadGateway {
+		t.Fatalf("Stored errors %v, want one with HTTP code %d", errs, http.StatusBadGateway)
+	}
+
+	// Clearing the faults restores the service
+	if rec := h.admin(http.MethodDelete, "/admin/faults", nil); rec.Code != http.StatusNoContent {
+		t.Fatalf("DELETE /admin/faults answered %d: %s", rec.Code, rec.Body)
+	}
+	var resp api.TemperatureEnvelope
+	h.getJSON(temperaturePath, http.StatusOK, &resp)
+}
+
+func TestScrapperDatabaseFault(t *testing.T) {
+	h := newHarness(t)
+	h.setFaults(api.Fault{Target: faults.ScrapperDatabase, ErrorRate: 1, Code: "RESOURCE_EXHAUSTED"})
+
+	// The reading is answered and kept in the outbox
+	var resp api.TemperatureEnvelope
+	h.getJSON(temperaturePath, http.StatusOK, &resp)
+
+	if n := h.meteo.requests(); n != 1 {
+		t.Errorf("Open-Meteo was called %d times, want 1", n)
+	}
+	if readings := h.readings(latitude, longitude, false); len(readings) != 0 {
+		t.Errorf("Stored %d readings, want none", len(readings))
+	}
+
+	// Retries fail while the fault lasts
+	if delivered, err := h.flushOutbox(); delivered != 0 || err == nil {
+		t.Errorf("Delivered %d readings with error %v, want a failure", delivered, err)
+	}
+	if n := h.outbox.Len(); n != 1 {
+		t.Errorf("Outbox holds %d readings, want 1", n)
+	}
+}
+
+func TestDatabaseLatency(t *testing.T) {
+	h := newHarness(t, withConfig(func(cfg *config.Config) {
+		cfg.API.RequestTimeout = 200 * time.Millisecond
+	}))
+	h.setFaults(api.Fault{Target: faults.Database, LatencyMs: 1000})
+
+	var failed api.ErrorResponse
+	h.getJSON(temperaturePath, http.StatusGatewayTimeout, &failed)
+}
+
+func TestListFaults(t *testing.T) {
+	h := newHarness(t)
+	h.setFaults(
+		api.Fault{Target: faults.Mongo, ErrorRate: 0.5},
+		api.Fault{Target: faults.Database, LatencyMs: 50, ErrorRate: 0.1},
+		api.Fault{Target: faults.OpenMeteo, LatencyMs: 100},
+	)
+
+	var resp api.FaultsResponse
+	rec := h.admin(http.MethodGet, "/admin/faults", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /admin/faults answered %d: %s", rec.Code, rec.Body)
+	}
+	decode(t, rec.Body.Bytes(), &resp)
+
+	want := []api.Fault{
+		{Target: faults.OpenMeteo, LatencyMs: 100},
+		{Target: faults.Database, LatencyMs: 50, ErrorRate: 0.1, Code: "UNAVAILABLE"},
+		{Target: faults.Mongo, ErrorRate: 0.5},
+	}
+	if len(resp.Faults) != len(want) {
+		t.Fatalf("Listed faults %+v, want %+v", resp.Faults, want)
+	}
+	for i := range want {
+		if resp.Faults[i] != want[i] {
+			t.Errorf("Listed fault %+v, want %+v", resp.Faults[i], want[i])
+		}
+	}
+}
+
+func TestInvalidFaults(t *testing.T) {
+	h := newHarness(t)
+
+	tests := []struct {
+		name  string
+		fault api.Fault
+	}{
+		{"unknown target", api.Fault{Target: "redis", ErrorRate: 1}},
+		{"error rate above 1", api.Fault{Target: faults.Mongo, ErrorRate: 2}},
+		{"negative latency", api.Fault{Target: faults.Database, LatencyMs: -1}},
+		{"unknown code", api.Fault{Target: faults.Database, ErrorRate: 1, Code: "BROKEN"}},
+		{"HTTP status of a gRPC target", api.Fault{Target: faults.Database, ErrorRate: 1, HTTPStatus: 503}},
+		{"code of an HTTP target", api.Fault{Target: faults.OpenMeteo, ErrorRate: 1, Code: "UNAVAILABLE"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := h.admin(http.MethodPut, "/admin/faults", api.SetFaultsRequest{Faults: []api.Fault{tt.fault}})
+			if rec.Code != http.StatusBadRequest {
+				t.Errorf("PUT /admin/faults answered %d, want %d: %s", rec.Code, http.StatusBadRequest, rec.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,381 @@
+package integration
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/brochadoluis/temperature-exercise/internal/api"
+	"github.com/brochadoluis/temperature-exercise/internal/config"
+	"github.com/brochadoluis/temperature-exercise/internal/database"
+	"github.com/brochadoluis/temperature-exercise/internal/faults"
+	"github.com/brochadoluis/temperature-exercise/internal/fixtures"
+	"github.com/brochadoluis/temperature-exercise/internal/health"
+	"github.com/brochadoluis/temperature-exercise/internal/outbox"
+	"github.com/brochadoluis/temperature-exercise/internal/scrapper"
+	"github.com/brochadoluis/temperature-exercise/internal/servicetoken"
+	"github.com/brochadoluis/temperature-exercise/proto"
+)
+
+// bufferSize is the size of the in-memory connections between the services.
+const bufferSize = 1 << 20
+
+// adminToken guards the admin endpoints of the API.
+const adminToken = "test-admin-token"
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	logrus.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// harness runs the whole system in the test process. Requests go through the
+// Gin router, the scrapper fetches readings from the Open-Meteo stub and the
+// database service stores them in memory.
+type harness struct {
+	t      *testing.T
+	router *gin.Engine
+	meteo  *meteoStub
+	// client calls the database service directly as tempctl, to check what
+	// was stored.
+	client proto.TemperatureClient
+	// apiClient calls the database service with the identity of the API.
+	apiClient proto.TemperatureClient
+	// outbox holds the readings the scrapper failed to save. It is only
+	// delivered by flushOutbox, so tests decide when the retries happen.
+	outbox *outbox.Outbox
+}
+
+// option changes how newHarness wires the services.
+type option func(*options)
+
+type options struct {
+	// configure changes the configuration of the services.
+	configure []func(cfg *config.Config)
+}
+
+// withConfig changes the configuration the services are started with.
+func withConfig(configure func(cfg *config.Config)) option {
+	return func(o *options) {
+		o.configure = append(o.configure, configure)
+	}
+}
+
+// withFixtures makes the scrapper replay the Open-Meteo responses recorded in
+// dir instead of calling the stub.
+func withFixtures(dir string) option {
+	return withConfig(func(cfg *config.Config) {
+		cfg.Scrapper.FixturesMode = fixtures.ModeReplay
+		cfg.Scrapper.FixturesDir = dir
+	})
+}
+
+// newHarness starts the services and stops them when the test ends. Service
+// tokens are enabled, so the calls between the services are signed and
+// verified as in production, and so are fault injection and the admin
+// endpoints.
+func newHarness(t *testing.T, opts ...option) *harness {
+	t.Helper()
+
+	cfg := config.Default()
+	cfg.ServiceAuth = config.ServiceAuth{
+		Enabled:      true,
+		SigningKeyID: "test",
+		Keys: map[string]string{
+			"test":    strings.Repeat("k", 32),
+			"tempctl": strings.Repeat("t", 32),
+		},
+		KeyIssuers: map[string]string{"tempctl": servicetoken.Tempctl},
+		TokenTTL:   cfg.ServiceAuth.TokenTTL,
+	}
+	cfg.Auth.AdminToken = adminToken
+	cfg.Faults.Enabled = true
+	cfg.Scrapper.OutboxDir = t.TempDir()
+
+	meteo := newMeteoStub()
+	t.Cleanup(meteo.Close)
+
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	for _, configure := range o.configure {
+		configure(cfg)
+	}
+
+	// The scrapper calls the stub unless replaying fixtures
+	cfg.Scrapper.OpenMeteoURL = meteo.URL + "/v1/forecast"
+
+	// Database service
+	databaseServer := database.NewGRPCServer(cfg, database.NewMemoryStore(), nil)
+	databaseListener := serve(t, databaseServer.Server, databaseServer.Health)
+
+	// Scrapper service. The outbox is left to flushOutbox and the health
+	// checks are not run, so the stub only counts the requests of the tests
+	scrapperFaults := faults.NewInjector(cfg.Faults.Enabled, faults.ScrapperTargets...)
+	scrapperDatabaseConn := dial(t, databaseListener, scrapper.DialOptions(cfg, scrapperFaults)...)
+	scrapperServer, err := scrapper.NewGRPCServer(cfg, scrapperDatabaseConn, scrapperFaults, meteo.Client().Transport)
+	if err != nil {
+		t.Fatalf("Failed to set up the scrapper: %v", err)
+	}
+	scrapperListener := serve(t, scrapperServer.Server, scrapperServer.Health)
+
+	// API
+	apiSigner := servicetoken.NewSigner(servicetoken.API, cfg.ServiceAuth)
+	scrapperConn := dial(t, scrapperListener, api.DialOptions(cfg, apiSigner, servicetoken.Scrapper)...)
+	databaseConn := dial(t, databaseListener, api.DialOptions(cfg, apiSigner, servicetoken.Database)...)
+
+	// The database service only serves its history to tempctl, which signs
+	// with a key of its own
+	tempctlAuth := cfg.ServiceAuth
+	tempctlAuth.SigningKeyID = "tempctl"
+	tempctlSigner := servicetoken.NewSigner(servicetoken.Tempctl, tempctlAuth)
+	tempctlConn := dial(t, databaseListener, api.DialOptions(cfg, tempctlSigner, servicetoken.Database)...)
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	router, alertStream, err := api.NewRouter(cfg, scrapperConn, databaseConn, logger)
+	if err != nil {
+		t.Fatalf("Failed to set up the router: %v", err)
+	}
+	t.Cleanup(alertStream.Shutdown)
+
+	return &harness{
+		t:         t,
+		router:    router,
+		meteo:     meteo,
+		client:    proto.NewTemperatureClient(tempctlConn),
+		apiClient: proto.NewTemperatureClient(databaseConn),
+		outbox:    scrapperServer.Outbox,
+	}
+}
+
+// get serves a GET request through the router.
+func (h *harness) get(path string) *httptest.ResponseRecorder {
+	h.t.Helper()
+
+	rec := httptest.NewRecorder()
+	h.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	return rec
+}
+
+// admin serves a request to an admin endpoint, with body encoded as JSON
+// unless nil.
+func (h *harness) admin(method, path string, body interface{}) *httptest.ResponseRecorder {
+	h.t.Helper()
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			h.t.Fatalf("Failed to encode the body of %s %s: %v", method, path, err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	req.Header.Set("Authorization", "Bearer "+adminToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	rec := httptest.NewRecorder()
+	h.router.ServeHTTP(rec, req)
+	return rec
+}
+
+// setFaults replaces the injected faults and fails the test if they are
+// rejected.
+func (h *harness) setFaults(faults ...api.Fault) {
+	h.t.Helper()
+
+	rec := h.admin(http.MethodPut, "/admin/faults", api.SetFaultsRequest{Faults: faults})
+	if rec.Code != http.StatusOK {
+		h.t.Fatalf("PUT /admin/faults answered %d: %s", rec.Code, rec.Body)
+	}
+}
+
+// getJSON serves a GET request, checks its status and decodes its body into v.
+func (h *harness) getJSON(path string, wantStatus int, v interface{}) {
+	h.t.Helper()
+
+	rec := h.get(path)
+	if rec.Code != wantStatus {
+		h.t.Fatalf("GET %s answered %d, want %d: %s", path, rec.Code, wantStatus, rec.Body)
+	}
+	decode(h.t, rec.Body.Bytes(), v)
+}
+
+// decode decodes a JSON body into v.
+func decode(t *testing.T, body []byte, v interface{}) {
+	t.Helper()
+
+	if err := json.Unmarshal(body, v); err != nil {
+		t.Fatalf("Failed to decode %s: %v", body, err)
+	}
+}
+
+// readings lists the readings the database service stored for the location,
+// from the error collection when errs is set.
+func (h *harness) readings(latitude, longitude float64, errs bool) []*proto.Reading {
+	h.t.Helper()
+
+	resp, err := h.client.ListReadings(context.Background(), &proto.ListReadingsRequest{
+		Latitude:  latitude,
+		Longitude: longitude,
+		Errors:    errs,
+	})
+	if err != nil {
+		h.t.Fatalf("Failed to list readings: %v", err)
+	}
+	return resp.GetReadings()
+}
+
+// activeAlerts lists the alerts the database service has open.
+func (h *harness) activeAlerts() []*proto.AlertEvent {
+	h.t.Helper()
+
+	resp, err := h.client.ListActiveAlerts(context.Background(), &proto.ListActiveAlertsRequest{})
+	if err != nil {
+		h.t.Fatalf("Failed to list active alerts: %v", err)
+	}
+	return resp.GetAlerts()
+}
+
+// flushOutbox delivers the readings the scrapper failed to save, as its
+// retry loop does, and returns how many were saved.
+func (h *harness) flushOutbox() (int, error) {
+	h.t.Helper()
+
+	if h.outbox == nil {
+		h.t.Fatal("The outbox is disabled")
+	}
+	return h.outbox.Flush(context.Background())
+}
+
+// mustFlushOutbox flushes the outbox and fails the test unless it delivered
+// want readings.
+func (h *harness) mustFlushOutbox(want int) {
+	h.t.Helper()
+
+	if delivered, err := h.flushOutbox(); delivered != want || err != nil {
+		h.t.Fatalf("Delivered %d readings with error %v, want %d", delivered, err, want)
+	}
+}
+
+// serve reports the server as serving, as its health checks are not run,
+// and serves it on an in-memory listener.
+func serve(t *testing.T, server *grpc.Server, healthServer *grpchealth.Server) *bufconn.Listener {
+	t.Helper()
+
+	healthServer.SetServingStatus(health.Service, healthpb.HealthCheckResponse_SERVING)
+
+	listener := bufconn.Listen(bufferSize)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+	return listener
+}
+
+// dial connects to a server listening on the in-memory listener.
+func dial(t *testing.T, listener *bufconn.Listener, opts ...grpc.DialOption) *grpc.ClientConn {
+	t.Helper()
+
+	conn, err := grpc.Dial("bufconn",
+		append(opts,
+			grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+				return listener.DialContext(ctx)
+			}),
+			grpc.WithTransportCredentials(insecure.NewCredentials()),
+		)...,
+	)
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// meteoStub answers the current weather like Open-Meteo, for the location of
+// the request.
+type meteoStub struct {
+	*httptest.Server
+
+	mu          sync.Mutex
+	temperature float64
+	statusCode  int
+	calls       int
+}
+
+func newMeteoStub() *meteoStub {
+	m := &meteoStub{
+		temperature: 21.5,
+		statusCode:  http.StatusOK,
+	}
+	m.Server = httptest.NewServer(http.HandlerFunc(m.serve))
+	return m
+}
+
+// setTemperature sets the temperature of the next answers, in Celsius.
+func (m *meteoStub) setTemperature(temperature float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.temperature = temperature
+}
+
+// fail makes the next answers errors with the status code.
+func (m *meteoStub) fail(statusCode int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.statusCode = statusCode
+}
+
+// requests returns the number of requests answered.
+func (m *meteoStub) requests() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.calls
+}
+
+func (m *meteoStub) serve(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	m.calls++
+	temperature, statusCode := m.temperature, m.statusCode
+	m.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	if statusCode != http.StatusOK {
+		w.WriteHeader(statusCode)
+		fmt.Fprint(w, `{"error":true,"reason":"stub failure"}`)
+		return
+	}
+
+	latitude, _ := strconv.ParseFloat(r.URL.Query().Get("latitude"), 64)
+	longitude, _ := strconv.ParseFloat(r.URL.Query().Get("longitude"), 64)
+	json.NewEncoder(w).Encode(scrapper.Response{
+		Latitude:  latitude,
+		Longitude: longitude,
+		CurrentWeather: scrapper.Weather{
+			Temperature:   temperature,
+			WindSpeed:     10,
+			WindDirection: 90,
+			WeatherCode:   3,
+			IsDay:         1,
+			Time:          "2026-10-19T12:00",
+		},
+	})
+}
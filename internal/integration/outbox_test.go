@@ -0,0 +1,92 @@
+package integration
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/brochadoluis/temperature-exercise/internal/api"
+	"github.com/brochadoluis/temperature-exercise/internal/config"
+	"github.com/brochadoluis/temperature-exercise/internal/faults"
+)
+
+func TestOutboxDisabled(t *testing.T) {
+	h := newHarness(t, withConfig(func(cfg *config.Config) {
+		cfg.Scrapper.OutboxDir = ""
+	}))
+	h.setFaults(api.Fault{Target: faults.Mongo, ErrorRate: 1})
+
+	// Without an outbox the reading is lost with the save
+	var resp api.ErrorResponse
+	h.getJSON(temperaturePath, http.StatusInternalServerError, &resp)
+	if resp.Error == "" {
+		t.Error("Error message is missing")
+	}
+
+	h.setFaults()
+	if readings := h.readings(latitude, longitude, false); len(readings) != 0 {
+		t.Errorf("Stored %d readings, want none", len(readings))
+	}
+}
+
+func TestOutboxUpstreamError(t *testing.T) {
+	h := newHarness(t)
+	h.meteo.fail(http.StatusServiceUnavailable)
+	h.setFaults(api.Fault{Target: faults.Database, ErrorRate: 1})
+
+	var resp api.ErrorResponse
+	h.getJSON(temperaturePath, http.StatusInternalServerError, &resp)
+
+	// The failed Open-Meteo call is recorded once the database service is back
+	h.setFaults()
+	h.mustFlushOutbox(1)
+	errs := h.readings(latitude, longitude, true)
+	if len(errs) != 1 {
+		t.Fatalf("Stored %d errors, want 1", len(errs))
+	}
+	if errs[0].GetHttpCode() != http.StatusServiceUnavailable {
+		t.Errorf("Stored HTTP code %d, want %d", errs[0].GetHttpCode(), http.StatusServiceUnavailable)
+	}
+	if n := h.outbox.Len(); n != 0 {
+		t.Errorf("Outbox holds %d readings, want none", n)
+	}
+}
+
+func TestOutboxRacingSave(t *testing.T) {
+	// A newer reading within the thresholds is saved directly while an
+	// older alert reading waits in the outbox. Whichever of the two reaches
+	// the database service first, the newer reading decides the alert state
+	tests := []struct {
+		name       string
+		flushFirst bool
+	}{
+		{"backlog flushed first", true},
+		{"live save first", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newHarness(t)
+
+			h.meteo.setTemperature(45)
+			h.setFaults(api.Fault{Target: faults.Mongo, ErrorRate: 1})
+			var resp api.TemperatureEnvelope
+			h.getJSON(temperaturePath, http.StatusOK, &resp)
+			h.setFaults()
+
+			h.meteo.setTemperature(20)
+			if tt.flushFirst {
+				h.mustFlushOutbox(1)
+				h.getJSON(temperaturePath, http.StatusOK, &resp)
+			} else {
+				h.getJSON(temperaturePath, http.StatusOK, &resp)
+				h.mustFlushOutbox(1)
+			}
+
+			if alerts := h.activeAlerts(); len(alerts) != 0 {
+				t.Errorf("%d alerts are open after the newer reading, want none", len(alerts))
+			}
+			if readings := h.readings(latitude, longitude, false); len(readings) != 2 {
+				t.Errorf("Stored %d readings, want 2", len(readings))
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package integration
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/brochadoluis/temperature-exercise/internal/api"
+)
+
+// openMeteoFixtures holds recorded Open-Meteo responses, see
+// scrapper.fixtures_mode to record new ones.
+const openMeteoFixtures = "testdata/open-meteo"
+
+func TestReplayedReading(t *testing.T) {
+	h := newHarness(t, withFixtures(openMeteoFixtures))
+
+	var resp api.TemperatureEnvelope
+	h.getJSON(temperaturePath, http.StatusOK, &resp)
+
+	// Open-Meteo answers for the nearest point of its grid
+	if resp.Data.Latitude != 38.72 || resp.Data.Longitude != -9.119999 {
+		t.Errorf("Location is %v,%v, want the grid point 38.72,-9.119999", resp.Data.Latitude, resp.Data.Longitude)
+	}
+	if resp.Data.Temperature != 17.3 || resp.Data.WindSpeed != 14.8 || resp.Data.WindDirection != 337 {
+		t.Errorf("Reading is %+v", resp.Data)
+	}
+	if resp.Data.Condition != "Partly cloudy" || !resp.Data.IsDay {
+		t.Errorf("Condition is %q by day %v, want Partly cloudy by day", resp.Data.Condition, resp.Data.IsDay)
+	}
+	if got := resp.ObservedAt.Format("2006-01-02T15:04"); got != "2026-10-19T12:00" {
+		t.Errorf("Observed at %s, want 2026-10-19T12:00", got)
+	}
+
+	if n := h.meteo.requests(); n != 0 {
+		t.Errorf("The stub was called %d times, want none", n)
+	}
+	if readings := h.readings(latitude, longitude, false); len(readings) != 1 {
+		t.Errorf("Stored %d readings, want 1", len(readings))
+	}
+}
+
+func TestReplayedUpstreamError(t *testing.T) {
+	h := newHarness(t, withFixtures(openMeteoFixtures))
+
+	var resp api.ErrorResponse
+	h.getJSON("/v2/temperature?latitude=95&longitude=-9.1", http.StatusInternalServerError, &resp)
+
+	errs := h.readings(95, longitude, true)
+	if len(errs) != 1 {
+		t.Fatalf("Stored %d errors, want 1", len(errs))
+	}
+	if errs[0].GetHttpCode() != http.StatusBadRequest {
+		t.Errorf("Stored HTTP code %d, want %d", errs[0].GetHttpCode(), http.StatusBadRequest)
+	}
+}
+
+func TestReplayMissingFixture(t *testing.T) {
+	h := newHarness(t, withFixtures(openMeteoFixtures))
+
+	var resp api.ErrorResponse
+	h.getJSON("/v2/temperature?latitude=1&longitude=2", http.StatusInternalServerError, &resp)
+
+	if n := h.meteo.requests(); n != 0 {
+		t.Errorf("The stub was called %d times, want none", n)
+	}
+}
@@ -0,0 +1,274 @@
+package integration
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/brochadoluis/temperature-exercise/internal/api"
+	"github.com/brochadoluis/temperature-exercise/internal/faults"
+	"github.com/brochadoluis/temperature-exercise/proto"
+)
+
+const (
+	latitude  = 38.7
+	longitude = -9.1
+)
+
+const temperaturePath = "/v2/temperature?latitude=38.7&longitude=-9.1"
+
+func TestTemperature(t *testing.T) {
+	h := newHarness(t)
+
+	var resp api.TemperatureEnvelope
+	h.getJSON(temperaturePath, http.StatusOK, &resp)
+
+	if resp.Data.Latitude != latitude || resp.Data.Longitude != longitude {
+		t.Errorf("Location is %v,%v, want %v,%v", resp.Data.Latitude, resp.Data.Longitude, latitude, longitude)
+	}
+	if resp.Data.Temperature != 21.5 {
+		t.Errorf("Temperature is %v, want 21.5", resp.Data.Temperature)
+	}
+	if resp.Data.Condition != "Overcast" {
+		t.Errorf("Condition is %q, want Overcast", resp.Data.Condition)
+	}
+	if resp.Data.Alert || resp.Data.Error {
+		t.Errorf("Reading is flagged, alert %v and error %v", resp.Data.Alert, resp.Data.Error)
+	}
+	if want := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC); !resp.ObservedAt.Equal(want) {
+		t.Errorf("Observed at %v, want %v", resp.ObservedAt, want)
+	}
+	if resp.RequestID == "" {
+		t.Error("Request ID is missing")
+	}
+
+	readings := h.readings(latitude, longitude, false)
+	if len(readings) != 1 {
+		t.Fatalf("Stored %d readings, want 1", len(readings))
+	}
+	if readings[0].GetTemperature() != 21.5 || readings[0].GetHttpCode() != http.StatusOK {
+		t.Errorf("Stored reading is %v", readings[0])
+	}
+	if errs := h.readings(latitude, longitude, true); len(errs) != 0 {
+		t.Errorf("Stored %d errors, want none", len(errs))
+	}
+	if alerts := h.activeAlerts(); len(alerts) != 0 {
+		t.Errorf("Opened %d alerts, want none", len(alerts))
+	}
+}
+
+func TestTemperatureV1(t *testing.T) {
+	h := newHarness(t)
+
+	rec := h.get("/getTemperature?latitude=38.7&longitude=-9.1")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /getTemperature answered %d: %s", rec.Code, rec.Body)
+	}
+	if got := rec.Header().Get("Deprecation"); got != "true" {
+		t.Errorf("Deprecation header is %q, want true", got)
+	}
+
+	var resp api.TemperatureResponse
+	h.getJSON("/v1/getTemperature?latitude=38.7&longitude=-9.1", http.StatusOK, &resp)
+	if resp.Temperature != 21.5 {
+		t.Errorf("Temperature is %v, want 21.5", resp.Temperature)
+	}
+
+	// The v1 body is not the proto message the gateway would answer, which is
+	// why the v1 routes are not served by the gateway
+	var legacy, gateway map[string]interface{}
+	h.getJSON("/v1/getTemperature?latitude=38.7&longitude=-9.1&units=imperial", http.StatusOK, &legacy)
+	h.getJSON("/api/temperature?latitude=38.7&longitude=-9.1", http.StatusOK, &gateway)
+	for _, field := range []string{"Temperature", "TemperatureUnit", "AlertThresholds"} {
+		if _, ok := legacy[field]; !ok {
+			t.Errorf("v1 body %v has no %s", legacy, field)
+		}
+	}
+	if legacy["Temperature"] != 70.7 || gateway["temperature"] != 21.5 {
+		t.Errorf("Temperature is %v in v1 and %v in the gateway, want 70.7 and 21.5", legacy["Temperature"], gateway["temperature"])
+	}
+}
+
+func TestAlert(t *testing.T) {
+	h := newHarness(t)
+	h.meteo.setTemperature(45)
+
+	var resp api.TemperatureEnvelope
+	h.getJSON(temperaturePath, http.StatusOK, &resp)
+
+	if !resp.Data.Alert {
+		t.Error("Reading above the high threshold is not an alert")
+	}
+
+	alerts := h.activeAlerts()
+	if len(alerts) != 1 {
+		t.Fatalf("Opened %d alerts, want 1", len(alerts))
+	}
+	if alerts[0].GetTemperature() != 45 {
+		t.Errorf("Alert is for %v, want 45", alerts[0].GetTemperature())
+	}
+
+	// The alert closes once the temperature is back within the thresholds
+	h.meteo.setTemperature(20)
+	h.getJSON(temperaturePath, http.StatusOK, &resp)
+	if resp.Data.Alert {
+		t.Error("Reading within the thresholds is an alert")
+	}
+	if alerts := h.activeAlerts(); len(alerts) != 0 {
+		t.Errorf("%d alerts are still open, want none", len(alerts))
+	}
+}
+
+func TestUpstreamError(t *testing.T) {
+	h := newHarness(t)
+	h.meteo.fail(http.StatusServiceUnavailable)
+
+	var resp api.ErrorResponse
+	h.getJSON(temperaturePath, http.StatusInternalServerError, &resp)
+	if resp.Error == "" {
+		t.Error("Error message is missing")
+	}
+
+	if readings := h.readings(latitude, longitude, false); len(readings) != 0 {
+		t.Errorf("Stored %d readings, want none", len(readings))
+	}
+	errs := h.readings(latitude, longitude, true)
+	if len(errs) != 1 {
+		t.Fatalf("Stored %d errors, want 1", len(errs))
+	}
+	if !errs[0].GetError() || errs[0].GetHttpCode() != http.StatusServiceUnavailable {
+		t.Errorf("Stored error is %v", errs[0])
+	}
+}
+
+func TestDatabaseFailure(t *testing.T) {
+	h := newHarness(t)
+	h.setFaults(api.Fault{Target: faults.Mongo, ErrorRate: 1})
+
+	// The reading fetched from Open-Meteo is still answered
+	var resp api.TemperatureEnvelope
+	h.getJSON(temperaturePath, http.StatusOK, &resp)
+	if resp.Data.Temperature != 21.5 {
+		t.Errorf("Temperature is %v, want 21.5", resp.Data.Temperature)
+	}
+
+	if n := h.meteo.requests(); n != 1 {
+		t.Errorf("Open-Meteo was called %d times, want 1", n)
+	}
+	if readings := h.readings(latitude, longitude, false); len(readings) != 0 {
+		t.Errorf("Stored %d readings, want none", len(readings))
+	}
+
+	// It is saved once MongoDB is back
+	h.setFaults()
+	h.mustFlushOutbox(1)
+	if readings := h.readings(latitude, longitude, false); len(readings) != 1 {
+		t.Errorf("Stored %d readings, want 1", len(readings))
+	}
+}
+
+func TestLateReadingOrder(t *testing.T) {
+	h := newHarness(t)
+
+	// The first reading waits in the outbox while a newer one is saved
+	h.setFaults(api.Fault{Target: faults.Mongo, ErrorRate: 1})
+	h.meteo.setTemperature(15)
+	h.getJSON(temperaturePath, http.StatusOK, &api.TemperatureEnvelope{})
+	h.setFaults()
+	h.meteo.setTemperature(25)
+	h.getJSON(temperaturePath, http.StatusOK, &api.TemperatureEnvelope{})
+	h.mustFlushOutbox(1)
+
+	readings := h.readings(latitude, longitude, false)
+	if len(readings) != 2 {
+		t.Fatalf("Stored %d readings, want 2", len(readings))
+	}
+	if got := readings[0].GetTemperature(); got != 25 {
+		t.Errorf("Newest reading is %v°C, want the live 25°C", got)
+	}
+
+	resp, err := h.client.ListReadings(context.Background(), &proto.ListReadingsRequest{
+		Latitude:  latitude,
+		Longitude: longitude,
+		Limit:     1,
+	})
+	if err != nil {
+		t.Fatalf("Failed to list readings: %v", err)
+	}
+	if got := resp.GetReadings(); len(got) != 1 || got[0].GetTemperature() != 25 {
+		t.Errorf("Latest reading is %v, want the live 25°C", got)
+	}
+}
+
+func TestReady(t *testing.T) {
+	h := newHarness(t)
+
+	rec := h.get("/readyz")
+	if rec.Code != http.StatusOK {
+		t.Errorf("GET /readyz answered %d: %s", rec.Code, rec.Body)
+	}
+}
+
+func TestHistoryRestrictedToTempctl(t *testing.T) {
+	h := newHarness(t)
+
+	_, err := h.apiClient.ListReadings(context.Background(), &proto.ListReadingsRequest{})
+	if status.Code(err) != codes.PermissionDenied {
+		t.Errorf("ListReadings as the API failed with %v, want PermissionDenied", err)
+	}
+	_, err = h.apiClient.ListActiveAlerts(context.Background(), &proto.ListActiveAlertsRequest{})
+	if status.Code(err) != codes.PermissionDenied {
+		t.Errorf("ListActiveAlerts as the API failed with %v, want PermissionDenied", err)
+	}
+}
+
+func TestWatchlist(t *testing.T) {
+	h := newHarness(t)
+	ctx := context.Background()
+
+	h.getJSON(temperaturePath, http.StatusOK, &api.TemperatureEnvelope{})
+
+	for _, req := range []*proto.AddToWatchlistRequest{
+		{Name: "lisbon", Latitude: latitude, Longitude: longitude},
+		{Name: "berlin", Latitude: 52.52, Longitude: 13.4},
+	} {
+		if _, err := h.client.AddToWatchlist(ctx, req); err != nil {
+			t.Fatalf("Failed to add %s to the watchlist: %v", req.GetName(), err)
+		}
+	}
+	_, err := h.client.AddToWatchlist(ctx, &proto.AddToWatchlistRequest{Name: "lisbon", Latitude: latitude, Longitude: longitude})
+	if status.Code(err) != codes.AlreadyExists {
+		t.Errorf("Adding lisbon twice failed with %v, want AlreadyExists", err)
+	}
+
+	resp, err := h.client.ListWatchlist(ctx, &proto.ListWatchlistRequest{})
+	if err != nil {
+		t.Fatalf("Failed to list the watchlist: %v", err)
+	}
+	entries := resp.GetEntries()
+	if len(entries) != 2 || entries[0].GetName() != "berlin" || entries[1].GetName() != "lisbon" {
+		t.Fatalf("Watchlist is %v, want berlin and lisbon", entries)
+	}
+	if entries[0].GetLatest() != nil {
+		t.Errorf("Berlin has latest reading %v, want none", entries[0].GetLatest())
+	}
+	if entries[1].GetLatest().GetTemperature() != 21.5 {
+		t.Errorf("Lisbon has latest reading %v, want 21.5", entries[1].GetLatest())
+	}
+
+	if _, err := h.client.RemoveFromWatchlist(ctx, &proto.RemoveFromWatchlistRequest{Name: "lisbon"}); err != nil {
+		t.Fatalf("Failed to remove lisbon from the watchlist: %v", err)
+	}
+	_, err = h.client.RemoveFromWatchlist(ctx, &proto.RemoveFromWatchlistRequest{Name: "lisbon"})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("Removing lisbon twice failed with %v, want NotFound", err)
+	}
+	_, err = h.apiClient.ListWatchlist(ctx, &proto.ListWatchlistRequest{})
+	if status.Code(err) != codes.PermissionDenied {
+		t.Errorf("ListWatchlist as the API failed with %v, want PermissionDenied", err)
+	}
+}
@@ -0,0 +1,261 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+const namespace = "temperature"
+
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests handled by the API, by route, method and status code.",
+	}, []string{"route", "method", "code"})
+
+	httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Latency of the HTTP requests handled by the API.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method", "code"})
+
+	grpcServerHandled = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "grpc_server_handled_total",
+		Help:      "gRPC calls completed by the server, by method and status code.",
+	}, []string{"method", "code"})
+
+	grpcServerDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "grpc_server_handling_seconds",
+		Help:      "Latency of the gRPC calls handled by the server.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method"})
+
+	grpcClientHandled = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "grpc_client_handled_total",
+		Help:      "gRPC calls completed by the client, by method and status code.",
+	}, []string{"method", "code"})
+
+	grpcClientDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "grpc_client_handling_seconds",
+		Help:      "Latency of the gRPC calls made by the client.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method"})
+
+	upstreamRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "upstream_requests_total",
+		Help:      "Requests made to Open-Meteo, by status code. Transport failures use code \"error\".",
+	}, []string{"code"})
+
+	upstreamDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "upstream_request_duration_seconds",
+		Help:      "Latency of the requests made to Open-Meteo.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"code"})
+
+	mongoInserts = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "mongo_inserts_total",
+		Help:      "Documents inserted into MongoDB, by collection and result.",
+	}, []string{"collection", "result"})
+
+	mongoInsertDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "mongo_insert_duration_seconds",
+		Help:      "Latency of the MongoDB inserts, by collection.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"collection"})
+
+	activeAlerts = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "active_alerts",
+		Help:      "Locations whose latest reading is outside of the alert thresholds.",
+	})
+
+	faultsInjected = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "faults_injected_total",
+		Help:      "Calls failed on purpose by fault injection, by target.",
+	}, []string{"target"})
+
+	outboxBacklog = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "outbox_backlog",
+		Help:      "Readings in the scrapper outbox waiting to be saved by the database service.",
+	})
+
+	outboxOldestAge = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "outbox_oldest_age_seconds",
+		Help:      "Age of the oldest reading in the scrapper outbox, 0 when it is empty.",
+	})
+
+	outboxDeliveries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "outbox_deliveries_total",
+		Help:      "Attempts to save a reading of the scrapper outbox, by result: delivered, failed or dropped.",
+	}, []string{"result"})
+)
+
+// Handler serves the metrics in the Prometheus exposition format.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// Serve starts an HTTP server exposing /metrics on addr. The returned server
+// is meant to be shut down with the rest of the binary.
+func Serve(addr string, errs chan<- error) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", Handler())
+
+	server := &http.Server{
+		Addr:    addr,
+		Handler: mux,
+	}
+	go func() {
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			errs <- errors.Wrap(err, "metrics server")
+		}
+	}()
+	return server
+}
+
+// Gin records the latency and status of every request handled by the router.
+func Gin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		code := strconv.Itoa(c.Writer.Status())
+
+		httpRequests.WithLabelValues(route, c.Request.Method, code).Inc()
+		httpDuration.WithLabelValues(route, c.Request.Method, code).Observe(time.Since(start).Seconds())
+	}
+}
+
+// UnaryServerInterceptor records the outcome and latency of unary calls.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		observeServer(info.FullMethod, start, err)
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor records the outcome and duration of streaming calls.
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		observeServer(info.FullMethod, start, err)
+		return err
+	}
+}
+
+func observeServer(method string, start time.Time, err error) {
+	grpcServerHandled.WithLabelValues(method, status.Code(err).String()).Inc()
+	grpcServerDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+}
+
+// UnaryClientInterceptor records the outcome and latency of outgoing unary
+// calls.
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		start := time.Now()
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		grpcClientHandled.WithLabelValues(method, status.Code(err).String()).Inc()
+		grpcClientDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+		return err
+	}
+}
+
+// StreamClientInterceptor records whether outgoing streams could be opened.
+// The lifetime of a stream is owned by the caller and is not measured.
+func StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		stream, err := streamer(ctx, desc, cc, method, opts...)
+		grpcClientHandled.WithLabelValues(method, status.Code(err).String()).Inc()
+		return stream, err
+	}
+}
+
+// Transport wraps an HTTP transport to record the requests made to Open-Meteo.
+func Transport(next http.RoundTripper) http.RoundTripper {
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		start := time.Now()
+		resp, err := next.RoundTrip(req)
+
+		code := "error"
+		if err == nil {
+			code = strconv.Itoa(resp.StatusCode)
+		}
+		upstreamRequests.WithLabelValues(code).Inc()
+		upstreamDuration.WithLabelValues(code).Observe(time.Since(start).Seconds())
+
+		return resp, err
+	})
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// ObserveInsert records a MongoDB insert into collection.
+func ObserveInsert(collection string, start time.Time, err error) {
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	mongoInserts.WithLabelValues(collection, result).Inc()
+	mongoInsertDuration.WithLabelValues(collection).Observe(time.Since(start).Seconds())
+}
+
+// SetActiveAlerts sets the number of locations currently in alert.
+func SetActiveAlerts(count int) {
+	activeAlerts.Set(float64(count))
+}
+
+// ObserveFault counts a call failed by fault injection.
+func ObserveFault(target string) {
+	faultsInjected.WithLabelValues(target).Inc()
+}
+
+// SetOutbox records the size of the scrapper outbox and the time its oldest
+// reading was queued at, zero when it is empty.
+func SetOutbox(backlog int, oldest time.Time) {
+	outboxBacklog.Set(float64(backlog))
+	if oldest.IsZero() {
+		outboxOldestAge.Set(0)
+		return
+	}
+	outboxOldestAge.Set(time.Since(oldest).Seconds())
+}
+
+// ObserveOutboxDelivery counts an attempt to save a reading of the outbox.
+func ObserveOutboxDelivery(result string) {
+	outboxDeliveries.WithLabelValues(result).Inc()
+}
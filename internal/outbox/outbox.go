@@ -0,0 +1,320 @@
+// Package outbox keeps the readings the database service failed to save in
+// files and delivers them in the background, so a reading already fetched
+// from Open-Meteo is not lost while the database service is unavailable.
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/brochadoluis/temperature-exercise/internal/metrics"
+	"github.com/brochadoluis/temperature-exercise/internal/requestid"
+	"github.com/brochadoluis/temperature-exercise/proto"
+)
+
+// Results of a delivery, as counted by metrics.ObserveOutboxDelivery.
+const (
+	resultDelivered = "delivered"
+	resultFailed    = "failed"
+	resultDropped   = "dropped"
+)
+
+const (
+	// deliverTimeout caps a single delivery to the database service.
+	deliverTimeout = 10 * time.Second
+	// fileSuffix ends the name of the file of a queued reading, tmpSuffix the
+	// name of a file still being written.
+	fileSuffix = ".json"
+	tmpSuffix  = ".tmp"
+)
+
+// ErrFull is returned by Enqueue when the outbox holds its maximum number of
+// readings.
+var ErrFull = errors.New("outbox is full")
+
+// Saver saves readings in the database service, like scrapper.Client.
+type Saver interface {
+	SaveTemperature(ctx context.Context, req *proto.SaveTemperatureRequest) (*proto.SaveTemperatureResponse, error)
+}
+
+type entry struct {
+	name     string
+	queuedAt time.Time
+}
+
+// Outbox is a queue of readings stored as one file per reading in a
+// directory, so they survive restarts. Readings are delivered in the order
+// they were queued, at least once: a save that timed out after the database
+// service stored it is delivered again.
+type Outbox struct {
+	dir      string
+	saver    Saver
+	interval time.Duration
+	max      int
+
+	mu      sync.Mutex
+	entries []entry
+	seq     uint64
+
+	// flushing lets a single Flush deliver at a time, so a reading is not
+	// sent twice by concurrent ones
+	flushing sync.Mutex
+}
+
+// New opens the outbox in dir, creating the directory if needed. Readings
+// left by a previous run are queued again. Run delivers them every interval,
+// and at most max readings are kept.
+func New(dir string, saver Saver, interval time.Duration, max int) (*Outbox, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, errors.Wrapf(err, "failed to create outbox directory %s", dir)
+	}
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read outbox directory %s", dir)
+	}
+
+	o := &Outbox{
+		dir:      dir,
+		saver:    saver,
+		interval: interval,
+		max:      max,
+	}
+	// ReadDir sorts by name, which sorts the readings by the time they were
+	// queued
+	for _, f := range files {
+		name := f.Name()
+		if strings.HasSuffix(name, tmpSuffix) {
+			// Left by a crash before the reading was queued
+			os.Remove(filepath.Join(dir, name))
+			continue
+		}
+		queuedAt, ok := parseName(name)
+		if !ok {
+			continue
+		}
+		o.entries = append(o.entries, entry{name: name, queuedAt: queuedAt})
+	}
+
+	o.mu.Lock()
+	o.report()
+	o.mu.Unlock()
+
+	if len(o.entries) > 0 {
+		log.Infof("Outbox %s holds %d readings from a previous run", dir, len(o.entries))
+	}
+	return o, nil
+}
+
+// Retryable reports whether a reading the database service failed to save
+// may be saved later. Readings it rejected as invalid never will.
+func Retryable(err error) bool {
+	return status.Code(err) != codes.InvalidArgument
+}
+
+// Enqueue stores req until it is delivered. The reading is on disk when
+// Enqueue returns.
+func (o *Outbox) Enqueue(req *proto.SaveTemperatureRequest) error {
+	data, err := protojson.Marshal(req)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode reading")
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if len(o.entries) >= o.max {
+		return ErrFull
+	}
+
+	now := time.Now()
+	o.seq++
+	name := fmt.Sprintf("%019d-%010d%s", now.UnixNano(), o.seq, fileSuffix)
+	if err := writeFile(o.dir, name, data); err != nil {
+		return errors.Wrap(err, "failed to write to the outbox")
+	}
+
+	o.entries = append(o.entries, entry{name: name, queuedAt: now})
+	o.report()
+	return nil
+}
+
+// Len returns the number of readings waiting to be delivered.
+func (o *Outbox) Len() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return len(o.entries)
+}
+
+// Run delivers the queued readings every interval until ctx is cancelled.
+func (o *Outbox) Run(ctx context.Context) {
+	ticker := time.NewTicker(o.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		// The age of the oldest reading grows between deliveries
+		o.mu.Lock()
+		o.report()
+		o.mu.Unlock()
+
+		if o.Len() == 0 {
+			continue
+		}
+
+		flushCtx := requestid.NewContext(ctx, requestid.New())
+		delivered, err := o.Flush(flushCtx)
+		if delivered > 0 {
+			log.WithContext(flushCtx).Infof("Delivered %d readings from the outbox", delivered)
+		}
+		if err != nil {
+			log.WithContext(flushCtx).Warnf("Failed to deliver the outbox, %d readings left: %v", o.Len(), err)
+		}
+	}
+}
+
+// Flush delivers the queued readings, oldest first, until the outbox is empty
+// or a delivery fails. Readings the database service rejects as invalid are
+// dropped. It returns the number of readings delivered.
+func (o *Outbox) Flush(ctx context.Context) (int, error) {
+	o.flushing.Lock()
+	defer o.flushing.Unlock()
+
+	delivered := 0
+	for {
+		o.mu.Lock()
+		if len(o.entries) == 0 {
+			o.mu.Unlock()
+			return delivered, nil
+		}
+		head := o.entries[0]
+		o.mu.Unlock()
+
+		err := o.deliver(ctx, head)
+		switch {
+		case err == nil:
+			delivered++
+			metrics.ObserveOutboxDelivery(resultDelivered)
+		case Retryable(err):
+			metrics.ObserveOutboxDelivery(resultFailed)
+			return delivered, err
+		default:
+			log.WithContext(ctx).Errorf("Dropping reading %s from the outbox: %v", head.name, err)
+			metrics.ObserveOutboxDelivery(resultDropped)
+		}
+
+		if err := o.remove(head); err != nil {
+			return delivered, err
+		}
+	}
+}
+
+// deliver saves the reading of e. Unreadable files fail as invalid, so they
+// are dropped instead of blocking the queue.
+func (o *Outbox) deliver(ctx context.Context, e entry) error {
+	data, err := os.ReadFile(filepath.Join(o.dir, e.name))
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "failed to read reading: %v", err)
+	}
+	req := &proto.SaveTemperatureRequest{}
+	if err := protojson.Unmarshal(data, req); err != nil {
+		return status.Errorf(codes.InvalidArgument, "failed to decode reading: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, deliverTimeout)
+	defer cancel()
+
+	_, err = o.saver.SaveTemperature(ctx, req)
+	return err
+}
+
+// remove deletes the file of e, the head of the queue.
+func (o *Outbox) remove(e entry) error {
+	if err := os.Remove(filepath.Join(o.dir, e.name)); err != nil && !os.IsNotExist(err) {
+		return errors.Wrapf(err, "failed to remove %s from the outbox", e.name)
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.entries = o.entries[1:]
+	o.report()
+	return nil
+}
+
+// report updates the outbox metrics. o.mu must be held.
+func (o *Outbox) report() {
+	var oldest time.Time
+	if len(o.entries) > 0 {
+		oldest = o.entries[0].queuedAt
+	}
+	metrics.SetOutbox(len(o.entries), oldest)
+}
+
+// parseName returns the time a reading was queued at from the name of its
+// file, which starts with the time in Unix nanoseconds.
+func parseName(name string) (time.Time, bool) {
+	if !strings.HasSuffix(name, fileSuffix) {
+		return time.Time{}, false
+	}
+	prefix, _, found := strings.Cut(name, "-")
+	if !found {
+		return time.Time{}, false
+	}
+	nanos, err := strconv.ParseInt(prefix, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(0, nanos), true
+}
+
+// writeFile writes data to name in dir through a temporary file, so a crash
+// never leaves a partial reading in the queue.
+func writeFile(dir, name string, data []byte) error {
+	tmp := filepath.Join(dir, name+tmpSuffix)
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, filepath.Join(dir, name)); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	// The rename is only durable once the directory is synced
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
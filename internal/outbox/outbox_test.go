@@ -0,0 +1,104 @@
+package outbox
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/brochadoluis/temperature-exercise/proto"
+)
+
+// saver records the readings it saves, or fails with err.
+type saver struct {
+	err   error
+	saved []float64
+}
+
+func (s *saver) SaveTemperature(ctx context.Context, req *proto.SaveTemperatureRequest) (*proto.SaveTemperatureResponse, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	s.saved = append(s.saved, req.GetTemperature())
+	return &proto.SaveTemperatureResponse{}, nil
+}
+
+func TestReopen(t *testing.T) {
+	dir := t.TempDir()
+	s := &saver{err: status.Error(codes.Unavailable, "database down")}
+
+	o, err := New(dir, s, time.Minute, 10)
+	if err != nil {
+		t.Fatalf("Failed to open the outbox: %v", err)
+	}
+	for _, temperature := range []float64{1, 2, 3} {
+		if err := o.Enqueue(&proto.SaveTemperatureRequest{Temperature: temperature}); err != nil {
+			t.Fatalf("Failed to enqueue: %v", err)
+		}
+	}
+	if delivered, err := o.Flush(context.Background()); delivered != 0 || err == nil {
+		t.Errorf("Delivered %d readings with error %v, want a failure", delivered, err)
+	}
+
+	// A file left by a crash mid-write is not a reading
+	if err := os.WriteFile(filepath.Join(dir, "1-1.json"+tmpSuffix), []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	s.err = nil
+	reopened, err := New(dir, s, time.Minute, 10)
+	if err != nil {
+		t.Fatalf("Failed to reopen the outbox: %v", err)
+	}
+	if n := reopened.Len(); n != 3 {
+		t.Fatalf("Reopened outbox holds %d readings, want 3", n)
+	}
+	if delivered, err := reopened.Flush(context.Background()); delivered != 3 || err != nil {
+		t.Fatalf("Delivered %d readings with error %v, want 3", delivered, err)
+	}
+	if len(s.saved) != 3 || s.saved[0] != 1 || s.saved[1] != 2 || s.saved[2] != 3 {
+		t.Errorf("Saved %v, want the readings in the order they were queued", s.saved)
+	}
+
+	files, _ := os.ReadDir(dir)
+	if len(files) != 0 {
+		t.Errorf("%d files left in the outbox, want none", len(files))
+	}
+}
+
+func TestFull(t *testing.T) {
+	o, err := New(t.TempDir(), &saver{}, time.Minute, 1)
+	if err != nil {
+		t.Fatalf("Failed to open the outbox: %v", err)
+	}
+
+	if err := o.Enqueue(&proto.SaveTemperatureRequest{}); err != nil {
+		t.Fatalf("Failed to enqueue: %v", err)
+	}
+	if err := o.Enqueue(&proto.SaveTemperatureRequest{}); err != ErrFull {
+		t.Errorf("Enqueue into a full outbox returned %v, want %v", err, ErrFull)
+	}
+}
+
+func TestDropInvalid(t *testing.T) {
+	s := &saver{err: status.Error(codes.InvalidArgument, "bad reading")}
+	o, err := New(t.TempDir(), s, time.Minute, 10)
+	if err != nil {
+		t.Fatalf("Failed to open the outbox: %v", err)
+	}
+	if err := o.Enqueue(&proto.SaveTemperatureRequest{}); err != nil {
+		t.Fatalf("Failed to enqueue: %v", err)
+	}
+
+	// Readings that can never be saved do not block the queue
+	if delivered, err := o.Flush(context.Background()); delivered != 0 || err != nil {
+		t.Errorf("Delivered %d readings with error %v, want the reading dropped", delivered, err)
+	}
+	if n := o.Len(); n != 0 {
+		t.Errorf("Outbox holds %d readings, want none", n)
+	}
+}
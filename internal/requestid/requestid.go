@@ -0,0 +1,138 @@
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	// Header is the HTTP header a request ID is accepted from and echoed in.
+	Header = "X-Request-ID"
+	// MetadataKey is the gRPC metadata key carrying the request ID.
+	MetadataKey = "x-request-id"
+	// LogField is the logrus field the request ID is logged under.
+	LogField = "request_id"
+
+	// maxLength bounds IDs accepted from clients so they cannot flood the logs.
+	maxLength = 128
+)
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying the request ID.
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the request ID carried by ctx, or an empty string.
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
+
+// New generates a random request ID.
+func New() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
+
+func valid(id string) bool {
+	return id != "" && len(id) <= maxLength
+}
+
+// Gin accepts the request ID from the X-Request-ID header or generates one,
+// stores it in the request context and echoes it in the response.
+func Gin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(Header)
+		if !valid(id) {
+			id = New()
+		}
+
+		c.Request = c.Request.WithContext(NewContext(c.Request.Context(), id))
+		c.Header(Header, id)
+		c.Next()
+	}
+}
+
+func outgoing(ctx context.Context) context.Context {
+	if id := FromContext(ctx); id != "" {
+		return metadata.AppendToOutgoingContext(ctx, MetadataKey, id)
+	}
+	return ctx
+}
+
+func incoming(ctx context.Context) context.Context {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if ids := md.Get(MetadataKey); len(ids) > 0 && valid(ids[0]) {
+		return NewContext(ctx, ids[0])
+	}
+	return NewContext(ctx, New())
+}
+
+// UnaryClientInterceptor sends the request ID of the context in the gRPC
+// metadata.
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return invoker(outgoing(ctx), method, req, reply, cc, opts...)
+	}
+}
+
+// StreamClientInterceptor sends the request ID of the context in the gRPC
+// metadata.
+func StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return streamer(outgoing(ctx), desc, cc, method, opts...)
+	}
+}
+
+// UnaryServerInterceptor stores the request ID received in the gRPC metadata
+// in the call context, generating one if the caller did not send it.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		return handler(incoming(ctx), req)
+	}
+}
+
+// StreamServerInterceptor stores the request ID received in the gRPC metadata
+// in the stream context, generating one if the caller did not send it.
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &serverStream{ServerStream: ss, ctx: incoming(ss.Context())})
+	}
+}
+
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+// Hook adds the request ID to every log entry created with WithContext.
+type Hook struct{}
+
+func (Hook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (Hook) Fire(entry *logrus.Entry) error {
+	if entry.Context == nil {
+		return nil
+	}
+	if id := FromContext(entry.Context); id != "" {
+		entry.Data[LogField] = id
+	}
+	return nil
+}
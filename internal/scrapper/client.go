@@ -38,3 +38,21 @@ func (c *Client) SaveTemperature(ctx context.Context, req *proto.SaveTemperature
 
 	return resp, nil
 }
+
+func (c *Client) SaveForecast(ctx context.Context, req *proto.SaveForecastRequest) (*proto.SaveForecastResponse, error) {
+	log := logrus.WithContext(ctx).WithField("method", "SaveForecast")
+
+	log.Infof("Sending SaveForecast request for latitude %f and longitude %f",
+		req.GetForecast().GetLatitude(),
+		req.GetForecast().GetLongitude())
+
+	resp, err := c.client.SaveForecast(ctx, req)
+	if err != nil {
+		log.Errorf("SaveForecast request failed: %v", err)
+		return nil, err
+	}
+
+	log.Infof("SaveForecast response received: %v", resp)
+
+	return resp, nil
+}
@@ -0,0 +1,195 @@
+package scrapper
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/brochadoluis/temperature-exercise/internal/deadline"
+	"github.com/brochadoluis/temperature-exercise/internal/tracing"
+	"github.com/brochadoluis/temperature-exercise/proto"
+)
+
+const (
+	defaultDays     = 7
+	maxDays         = 16
+	defaultVariable = "temperature_2m"
+)
+
+var variablePattern = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+// ForecastPayload is the part of an Open-Meteo forecast response holding the
+// requested hourly and daily arrays.
+type ForecastPayload struct {
+	Latitude    float64                    `json:"latitude"`
+	Longitude   float64                    `json:"longitude"`
+	Timezone    string                     `json:"timezone"`
+	HourlyUnits map[string]string          `json:"hourly_units"`
+	Hourly      map[string]json.RawMessage `json:"hourly"`
+	DailyUnits  map[string]string          `json:"daily_units"`
+	Daily       map[string]json.RawMessage `json:"daily"`
+	Error       bool                       `json:"error"`
+	Reason      string                     `json:"reason"`
+}
+
+func (s *Server) ListForecast(ctx context.Context, req *proto.ListForecastRequest) (*proto.ListForecastResponse, error) {
+	latitude := req.GetLatitude()
+	longitude := req.GetLongitude()
+
+	if err := checkCoordinates(ctx, latitude, longitude); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	days := int(req.GetDays())
+	if days == 0 {
+		days = defaultDays
+	}
+	if days < 1 || days > maxDays {
+		return nil, status.Errorf(codes.InvalidArgument, "days must be between 1 and %d", maxDays)
+	}
+
+	hourly := req.GetHourly()
+	daily := req.GetDaily()
+	if len(hourly) == 0 && len(daily) == 0 {
+		hourly = []string{defaultVariable}
+	}
+	for _, variable := range append(append([]string{}, hourly...), daily...) {
+		if !variablePattern.MatchString(variable) {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid variable %q", variable)
+		}
+	}
+
+	query := url.Values{}
+	query.Set("latitude", strconv.FormatFloat(latitude, 'f', -1, 64))
+	query.Set("longitude", strconv.FormatFloat(longitude, 'f', -1, 64))
+	query.Set("forecast_days", strconv.Itoa(days))
+	// Daily aggregations require a timezone
+	query.Set("timezone", "UTC")
+	if len(hourly) > 0 {
+		query.Set("hourly", strings.Join(hourly, ","))
+	}
+	if len(daily) > 0 {
+		query.Set("daily", strings.Join(daily, ","))
+	}
+
+	apiURL := s.BaseURL + "?" + query.Encode()
+	log.WithContext(ctx).Infof("Making API call to: %s", apiURL)
+
+	// The body is read under the same budget as the request itself
+	upstreamCtx, cancel := deadline.WithBudget(ctx, s.UpstreamTimeout, upstreamShare)
+	defer cancel()
+
+	resp, err := s.makeAPICall(upstreamCtx, apiURL)
+	if err != nil {
+		log.WithContext(ctx).Error(err)
+		return nil, errors.Wrap(err, "failed to make API call")
+	}
+	defer resp.Body.Close()
+
+	payload, err := s.parseForecast(upstreamCtx, resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse forecast")
+	}
+	if resp.StatusCode != http.StatusOK || payload.Error {
+		return nil, errors.Errorf("forecast request failed with status %d: %s", resp.StatusCode, payload.Reason)
+	}
+
+	forecast := &proto.ListForecastResponse{
+		Latitude:  payload.Latitude,
+		Longitude: payload.Longitude,
+		Timezone:  payload.Timezone,
+	}
+
+	forecast.Hourly, err = toTimeSeries(hourly, payload.Hourly, payload.HourlyUnits)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to map hourly forecast")
+	}
+
+	forecast.Daily, err = toTimeSeries(daily, payload.Daily, payload.DailyUnits)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to map daily forecast")
+	}
+
+	if req.GetStore() {
+		_, err := s.Client.SaveForecast(ctx, &proto.SaveForecastRequest{
+			Forecast: forecast,
+			Days:     int32(days),
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to save forecast")
+		}
+		forecast.Stored = true
+	}
+
+	return forecast, nil
+}
+
+func (s *Server) parseForecast(ctx context.Context, data io.Reader) (*ForecastPayload, error) {
+	ctx, span := tracing.Start(ctx, "scrapper.parseForecast")
+	defer span.End()
+
+	log.WithContext(ctx).Info("Parsing forecast response")
+
+	payload := &ForecastPayload{}
+	err := json.NewDecoder(data).Decode(payload)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse JSON response")
+	}
+
+	log.WithContext(ctx).Info("Forecast response parsed successfully")
+	return payload, nil
+}
+
+// toTimeSeries zips the time array of an hourly or daily block with each of the
+// requested variables. Missing values are skipped.
+func toTimeSeries(variables []string, block map[string]json.RawMessage, units map[string]string) ([]*proto.TimeSeries, error) {
+	if len(variables) == 0 {
+		return nil, nil
+	}
+
+	var times []string
+	if err := json.Unmarshal(block["time"], &times); err != nil {
+		return nil, errors.Wrap(err, "failed to parse time array")
+	}
+
+	series := make([]*proto.TimeSeries, 0, len(variables))
+	for _, variable := range variables {
+		raw, ok := block[variable]
+		if !ok {
+			return nil, errors.Errorf("variable %s missing from response", variable)
+		}
+
+		var values []*float64
+		if err := json.Unmarshal(raw, &values); err != nil {
+			return nil, errors.Wrapf(err, "failed to parse %s values", variable)
+		}
+		if len(values) != len(times) {
+			return nil, errors.Errorf("variable %s has %d values for %d times", variable, len(values), len(times))
+		}
+
+		ts := &proto.TimeSeries{
+			Variable: variable,
+			Unit:     units[variable],
+			Points:   make([]*proto.SeriesPoint, 0, len(values)),
+		}
+		for i, value := range values {
+			if value == nil {
+				continue
+			}
+			ts.Points = append(ts.Points, &proto.SeriesPoint{Time: times[i], Value: *value})
+		}
+		series = append(series, ts)
+	}
+
+	return series, nil
+}
@@ -0,0 +1,106 @@
+package scrapper
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/brochadoluis/temperature-exercise/internal/config"
+	"github.com/brochadoluis/temperature-exercise/internal/deadline"
+	"github.com/brochadoluis/temperature-exercise/internal/faults"
+	"github.com/brochadoluis/temperature-exercise/internal/fixtures"
+	"github.com/brochadoluis/temperature-exercise/internal/health"
+	"github.com/brochadoluis/temperature-exercise/internal/metrics"
+	"github.com/brochadoluis/temperature-exercise/internal/outbox"
+	"github.com/brochadoluis/temperature-exercise/internal/requestid"
+	"github.com/brochadoluis/temperature-exercise/internal/servicetoken"
+	"github.com/brochadoluis/temperature-exercise/internal/tracing"
+	"github.com/brochadoluis/temperature-exercise/proto"
+)
+
+// DialOptions returns the interceptors of the connection of the scrapper to
+// the database service. Calls are signed for the database service and fail
+// as set for the scrapper-database fault of the injector.
+func DialOptions(cfg *config.Config, injector *faults.Injector) []grpc.DialOption {
+	signer := servicetoken.NewSigner(servicetoken.Scrapper, cfg.ServiceAuth)
+	return []grpc.DialOption{
+		grpc.WithChainUnaryInterceptor(requestid.UnaryClientInterceptor(), tracing.UnaryClientInterceptor(), metrics.UnaryClientInterceptor(), signer.UnaryClientInterceptor(servicetoken.Database), injector.UnaryClientInterceptor(faults.ScrapperDatabase)),
+		grpc.WithChainStreamInterceptor(requestid.StreamClientInterceptor(), tracing.StreamClientInterceptor(), metrics.StreamClientInterceptor(), signer.StreamClientInterceptor(servicetoken.Database)),
+	}
+}
+
+// GRPCServer is the scrapper served over gRPC, with its health service.
+type GRPCServer struct {
+	*grpc.Server
+	Health *grpchealth.Server
+	// Outbox keeps the readings the database service failed to save, nil
+	// when no outbox directory is configured.
+	Outbox *outbox.Outbox
+
+	checks   map[string]health.Check
+	interval time.Duration
+}
+
+// NewGRPCServer registers the scrapper on a gRPC server with the
+// interceptors and health service of every deployment. Readings are saved
+// through databaseConn, dialed with DialOptions, and fetched from Open-Meteo
+// through upstream. opts carry the transport security, if any.
+func NewGRPCServer(cfg *config.Config, databaseConn *grpc.ClientConn, injector *faults.Injector, upstream http.RoundTripper, opts ...grpc.ServerOption) (*GRPCServer, error) {
+	// The scrapper has no writes of its own, only the API may change its
+	// faults. Other tokens are only checked when sent
+	verifier := servicetoken.NewVerifier(servicetoken.Scrapper, cfg.ServiceAuth, Policy)
+	server := grpc.NewServer(append(opts,
+		grpc.ChainUnaryInterceptor(requestid.UnaryServerInterceptor(), tracing.UnaryServerInterceptor(), metrics.UnaryServerInterceptor(), verifier.UnaryServerInterceptor(), deadline.UnaryServerInterceptor()),
+		grpc.ChainStreamInterceptor(requestid.StreamServerInterceptor(), tracing.StreamServerInterceptor(), metrics.StreamServerInterceptor(), verifier.StreamServerInterceptor()),
+	)...)
+
+	// Fixtures and faults sit below the metrics, so replayed responses and
+	// injected failures are still counted
+	upstream = injector.Transport(faults.OpenMeteo, fixtures.Transport(cfg.Scrapper.FixturesMode, cfg.Scrapper.FixturesDir, upstream))
+	httpClient := &http.Client{Transport: tracing.Transport(metrics.Transport(upstream))}
+
+	// Readings the database service fails to save are kept on disk and
+	// saved in the background, so they are still answered
+	client := NewClient(proto.NewTemperatureClient(databaseConn))
+	var queue *outbox.Outbox
+	if cfg.Scrapper.OutboxDir != "" {
+		var err error
+		queue, err = outbox.New(cfg.Scrapper.OutboxDir, client, cfg.Scrapper.OutboxRetryInterval, cfg.Scrapper.OutboxMaxEntries)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to open the outbox")
+		}
+	}
+	proto.RegisterTemperatureServer(server, NewServer(client, httpClient, cfg.Scrapper.OpenMeteoURL, cfg.Alerts.Thresholds(), cfg.Scrapper.UpstreamTimeout, injector, queue))
+
+	// Report the service as serving only while the database service and
+	// Open-Meteo are reachable. Replayed responses need no Open-Meteo.
+	checks := map[string]health.Check{
+		"database": health.GRPC(databaseConn),
+	}
+	if cfg.Scrapper.FixturesMode != fixtures.ModeReplay {
+		checks["open-meteo"] = health.HTTP(http.DefaultClient, cfg.Scrapper.OpenMeteoURL)
+	}
+	healthServer := grpchealth.NewServer()
+	healthpb.RegisterHealthServer(server, healthServer)
+
+	return &GRPCServer{
+		Server:   server,
+		Health:   healthServer,
+		Outbox:   queue,
+		checks:   checks,
+		interval: cfg.HealthInterval,
+	}, nil
+}
+
+// Run checks the dependencies and delivers the outbox until ctx is done.
+func (s *GRPCServer) Run(ctx context.Context) {
+	if s.Outbox != nil {
+		go s.Outbox.Run(ctx)
+	}
+	health.Run(ctx, s.Health, s.interval, s.checks)
+}
@@ -6,19 +6,35 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"time"
 
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
-
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/brochadoluis/temperature-exercise/internal/deadline"
+	"github.com/brochadoluis/temperature-exercise/internal/faults"
+	"github.com/brochadoluis/temperature-exercise/internal/outbox"
+	"github.com/brochadoluis/temperature-exercise/internal/servicetoken"
+	"github.com/brochadoluis/temperature-exercise/internal/tracing"
+	"github.com/brochadoluis/temperature-exercise/internal/units"
 	"github.com/brochadoluis/temperature-exercise/proto"
 )
 
 type ForecastResponse struct {
-	Latitude    float64
-	Longitude   float64
-	Temperature float64
-	Alert       bool
-	Error       bool
+	Latitude      float64
+	Longitude     float64
+	Temperature   float64
+	WindSpeed     float64
+	WindDirection float64
+	WeatherCode   int
+	IsDay         bool
+	Condition     string
+	ObservedAt    time.Time
+	Alert         bool
+	Error         bool
 }
 
 type Response struct {
@@ -28,12 +44,55 @@ type Response struct {
 }
 
 type Weather struct {
-	Temperature float64 `json:"temperature"`
+	Temperature   float64 `json:"temperature"`
+	WindSpeed     float64 `json:"windspeed"`
+	WindDirection float64 `json:"winddirection"`
+	WeatherCode   int     `json:"weathercode"`
+	IsDay         int     `json:"is_day"`
+	// Time is given in GMT, e.g. 2023-06-01T12:00.
+	Time string `json:"time"`
+}
+
+// currentTimeLayout is the format of the time of the current weather.
+const currentTimeLayout = "2006-01-02T15:04"
+
+// upstreamShare is the part of the time left to a call that Open-Meteo may
+// use. The rest is kept for saving the result in the database service.
+const upstreamShare = 0.7
+
+// Policy lets only the API change the faults injected by the scrapper. The
+// other calls need a token of any service, except for the health checks.
+var Policy = servicetoken.Policy{
+	"/temperature.Temperature/SetFaults": {servicetoken.API},
+	healthpb.Health_Check_FullMethodName: servicetoken.Public,
+	healthpb.Health_Watch_FullMethodName: servicetoken.Public,
 }
 
 type Server struct {
 	proto.UnimplementedTemperatureServer
-	Client *Client
+	Client          *Client
+	HTTPClient      *http.Client
+	BaseURL         string
+	Thresholds      units.Thresholds
+	UpstreamTimeout time.Duration
+	// Faults are injected into the requests to Open-Meteo and the calls to
+	// the database service, through HTTPClient and Client.
+	Faults *faults.Injector
+	// Outbox keeps the readings the database service failed to save, to
+	// save them later. Readings are not kept when nil.
+	Outbox *outbox.Outbox
+}
+
+func NewServer(client *Client, httpClient *http.Client, baseURL string, thresholds units.Thresholds, upstreamTimeout time.Duration, injector *faults.Injector, queue *outbox.Outbox) *Server {
+	return &Server{
+		Client:          client,
+		HTTPClient:      httpClient,
+		BaseURL:         baseURL,
+		Thresholds:      thresholds,
+		UpstreamTimeout: upstreamTimeout,
+		Faults:          injector,
+		Outbox:          queue,
+	}
 }
 
 // Ensure that the Server struct satisfies the temperatureServerType interface
@@ -50,45 +109,136 @@ func (s *Server) ListTemperature(ctx context.Context, req *proto.ListTemperature
 
 	err := checkCoordinates(ctx, latitude, longitude)
 	if err != nil {
-		log.Error("Coordinates are out of range")
+		log.WithContext(ctx).Error("Coordinates are out of range")
 		return &proto.ListTemperatureResponse{}, err
 	}
 
-	url := fmt.Sprintf("https://api.open-meteo.com/v1/forecast?latitude=%f&longitude=%f&current_weather=true", latitude, longitude)
+	url := fmt.Sprintf("%s?latitude=%f&longitude=%f&current_weather=true", s.BaseURL, latitude, longitude)
 	log.WithContext(ctx).Infof("Making API call to: %s", url)
 
-	resp, err := s.makeAPICall(ctx, url)
+	// The body is read under the same budget as the request itself
+	upstreamCtx, cancel := deadline.WithBudget(ctx, s.UpstreamTimeout, upstreamShare)
+	defer cancel()
+
+	resp, err := s.makeAPICall(upstreamCtx, url)
 	if err != nil {
-		log.Error(err)
+		log.WithContext(ctx).Error(err)
 		return nil, errors.Wrap(err, "failed to make API call")
 	}
+	// Saved with the reading, so it keeps this time if it is saved late
+	takenAt := time.Now()
+	// parseTemperature closes the body on success, this covers the error paths
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, s.saveUpstreamError(ctx, latitude, longitude, resp.StatusCode, takenAt)
+	}
 
-	forecast, err := s.parseTemperature(ctx, resp.Body)
+	forecast, err := s.parseTemperature(upstreamCtx, resp.Body)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to parse temperature")
 	}
 
-	forecast.setAlert(ctx)
+	forecast.setAlert(ctx, s.Thresholds)
+
+	trace.SpanFromContext(ctx).SetAttributes(
+		attribute.Float64("temperature", forecast.Temperature),
+		attribute.Bool("alert", forecast.Alert),
+	)
 
 	log.WithContext(ctx).Infof("Temperature for latitude %f and longitude %f: %f",
 		forecast.Latitude,
 		forecast.Longitude,
 		forecast.Temperature)
 
-	saved, err := s.Client.SaveTemperature(ctx, &proto.SaveTemperatureRequest{
-		Latitude:    forecast.Latitude,
-		Longitude:   forecast.Longitude,
-		Temperature: forecast.Temperature,
-		Alert:       forecast.Alert,
-		Error:       forecast.Error,
-		HttpCode:    int32(resp.StatusCode),
+	saved, err := s.save(ctx, &proto.SaveTemperatureRequest{
+		Latitude:      forecast.Latitude,
+		Longitude:     forecast.Longitude,
+		Temperature:   forecast.Temperature,
+		Alert:         forecast.Alert,
+		Error:         forecast.Error,
+		HttpCode:      int32(resp.StatusCode),
+		WindSpeed:     forecast.WindSpeed,
+		WindDirection: forecast.WindDirection,
+		WeatherCode:   int32(forecast.WeatherCode),
+		IsDay:         forecast.IsDay,
+		Condition:     forecast.Condition,
+		TakenAt:       takenAt.UnixNano(),
 	})
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to save temperature")
 	}
 
-	return toListTemperatureResponse(saved), nil
+	result := toListTemperatureResponse(saved)
+	if !forecast.ObservedAt.IsZero() {
+		result.ObservedAt = forecast.ObservedAt.Unix()
+	}
+	return result, nil
+}
+
+// saveUpstreamError records a failed Open-Meteo call in the error collection
+// and returns the error answered to the caller. The body of the failed call
+// holds no reading, so only the requested location is recorded.
+func (s *Server) saveUpstreamError(ctx context.Context, latitude, longitude float64, statusCode int, takenAt time.Time) error {
+	forecast := ForecastResponse{
+		Latitude:  latitude,
+		Longitude: longitude,
+	}
+	forecast.setError(ctx, uint32(statusCode))
+
+	_, err := s.save(ctx, &proto.SaveTemperatureRequest{
+		Latitude:  forecast.Latitude,
+		Longitude: forecast.Longitude,
+		Error:     forecast.Error,
+		HttpCode:  int32(statusCode),
+		TakenAt:   takenAt.UnixNano(),
+	})
+	if err != nil {
+		log.WithContext(ctx).Errorf("Failed to record Open-Meteo error: %v", err)
+	}
+
+	return errors.Errorf("open-meteo answered with status %d", statusCode)
+}
+
+// save saves req in the database service. When the database service fails
+// and the outbox takes the reading instead, the reading is answered as if it
+// had been saved.
+func (s *Server) save(ctx context.Context, req *proto.SaveTemperatureRequest) (*proto.SaveTemperatureResponse, error) {
+	saved, err := s.Client.SaveTemperature(ctx, req)
+	if err == nil || s.Outbox == nil || !outbox.Retryable(err) {
+		return saved, err
+	}
+
+	if queueErr := s.Outbox.Enqueue(req); queueErr != nil {
+		log.WithContext(ctx).Errorf("Failed to queue reading in the outbox: %v", queueErr)
+		return nil, err
+	}
+	log.WithContext(ctx).Warnf("Queued reading in the outbox after failing to save it: %v", err)
+
+	return &proto.SaveTemperatureResponse{
+		Latitude:      req.GetLatitude(),
+		Longitude:     req.GetLongitude(),
+		Temperature:   req.GetTemperature(),
+		Alert:         req.GetAlert(),
+		Error:         req.GetError(),
+		WindSpeed:     req.GetWindSpeed(),
+		WindDirection: req.GetWindDirection(),
+		WeatherCode:   req.GetWeatherCode(),
+		IsDay:         req.GetIsDay(),
+		Condition:     req.GetCondition(),
+	}, nil
+}
+
+// SetFaults replaces the faults injected by the scrapper.
+func (s *Server) SetFaults(ctx context.Context, req *proto.SetFaultsRequest) (*proto.SetFaultsResponse, error) {
+	return s.Faults.SetFaults(ctx, req)
+}
+
+// ListFaults returns the faults injected by the scrapper.
+func (s *Server) ListFaults(ctx context.Context, req *proto.ListFaultsRequest) (*proto.ListFaultsResponse, error) {
+	return s.Faults.ListFaults(ctx, req)
 }
+
 func checkCoordinates(ctx context.Context, latitude, longitude float64) error {
 	if latitude < -100 || latitude > 100 {
 		err := errors.New("latitude is out of range")
@@ -112,8 +262,7 @@ func (s *Server) makeAPICall(ctx context.Context, url string) (*http.Response, e
 		return nil, err
 	}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := s.HTTPClient.Do(req)
 	if err != nil {
 		log.WithContext(ctx).Error("Failed to make API call", err)
 		return nil, err
@@ -142,6 +291,9 @@ func (s *Server) parseResponse(ctx context.Context, data io.ReadCloser) (*Respon
 }
 
 func (s *Server) parseTemperature(ctx context.Context, data io.ReadCloser) (*ForecastResponse, error) {
+	ctx, span := tracing.Start(ctx, "scrapper.parseTemperature")
+	defer span.End()
+
 	log.WithContext(ctx).Info("Parsing response object")
 
 	var resp Response
@@ -160,9 +312,23 @@ func (s *Server) parseTemperature(ctx context.Context, data io.ReadCloser) (*For
 	log.WithContext(ctx).Info("Mapping response to forecast object")
 
 	forecast := ForecastResponse{
-		Latitude:    resp.Latitude,
-		Longitude:   resp.Longitude,
-		Temperature: resp.CurrentWeather.Temperature,
+		Latitude:      resp.Latitude,
+		Longitude:     resp.Longitude,
+		Temperature:   resp.CurrentWeather.Temperature,
+		WindSpeed:     resp.CurrentWeather.WindSpeed,
+		WindDirection: resp.CurrentWeather.WindDirection,
+		WeatherCode:   resp.CurrentWeather.WeatherCode,
+		IsDay:         resp.CurrentWeather.IsDay == 1,
+		Condition:     Condition(resp.CurrentWeather.WeatherCode),
+	}
+
+	if resp.CurrentWeather.Time != "" {
+		observedAt, err := time.Parse(currentTimeLayout, resp.CurrentWeather.Time)
+		if err != nil {
+			log.WithContext(ctx).Warnf("Failed to parse observation time %q: %v", resp.CurrentWeather.Time, err)
+		} else {
+			forecast.ObservedAt = observedAt
+		}
 	}
 
 	log.WithContext(ctx).Info("Response object parsed successfully")
@@ -170,10 +336,10 @@ func (s *Server) parseTemperature(ctx context.Context, data io.ReadCloser) (*For
 	return &forecast, nil
 }
 
-func (f *ForecastResponse) setAlert(ctx context.Context) {
+func (f *ForecastResponse) setAlert(ctx context.Context, thresholds units.Thresholds) {
 	log.WithContext(ctx).Infof("Setting alert for temperature: %f", f.Temperature)
 
-	f.Alert = f.Temperature < 10 || f.Temperature > 40
+	f.Alert = thresholds.Alert(f.Temperature)
 
 	log.WithContext(ctx).Infof("Alert set to: %v", f.Alert)
 }
@@ -190,10 +356,15 @@ func (f *ForecastResponse) setError(ctx context.Context, statusCode uint32) {
 
 func toListTemperatureResponse(s *proto.SaveTemperatureResponse) *proto.ListTemperatureResponse {
 	return &proto.ListTemperatureResponse{
-		Latitude:    s.GetLatitude(),
-		Longitude:   s.GetLongitude(),
-		Temperature: s.GetTemperature(),
-		Alert:       s.GetAlert(),
-		Error:       s.GetError(),
+		Latitude:      s.GetLatitude(),
+		Longitude:     s.GetLongitude(),
+		Temperature:   s.GetTemperature(),
+		Alert:         s.GetAlert(),
+		Error:         s.GetError(),
+		WindSpeed:     s.GetWindSpeed(),
+		WindDirection: s.GetWindDirection(),
+		WeatherCode:   s.GetWeatherCode(),
+		IsDay:         s.GetIsDay(),
+		Condition:     s.GetCondition(),
 	}
 }
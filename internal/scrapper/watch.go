@@ -0,0 +1,73 @@
+package scrapper
+
+import (
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/brochadoluis/temperature-exercise/proto"
+)
+
+const (
+	defaultWatchInterval = 60 * time.Second
+	minWatchInterval     = time.Second
+)
+
+// WatchTemperature streams a reading for the requested location every
+// interval until the client cancels. Each reading goes through the same fetch
+// and save path as ListTemperature. A failed reading is sent with Error set
+// instead of ending the stream.
+func (s *Server) WatchTemperature(req *proto.WatchTemperatureRequest, stream proto.Temperature_WatchTemperatureServer) error {
+	ctx := stream.Context()
+	latitude := req.GetLatitude()
+	longitude := req.GetLongitude()
+
+	if err := checkCoordinates(ctx, latitude, longitude); err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	interval := defaultWatchInterval
+	if req.GetIntervalSeconds() > 0 {
+		interval = time.Duration(req.GetIntervalSeconds()) * time.Second
+	}
+	if interval < minWatchInterval {
+		interval = minWatchInterval
+	}
+
+	log.WithContext(ctx).Infof("Watching temperature for latitude %f and longitude %f every %s", latitude, longitude, interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		resp, err := s.ListTemperature(ctx, &proto.ListTemperatureRequest{
+			Latitude:  latitude,
+			Longitude: longitude,
+		})
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			log.WithContext(ctx).Errorf("Failed to read temperature: %v", err)
+			resp = &proto.ListTemperatureResponse{
+				Latitude:  latitude,
+				Longitude: longitude,
+				Error:     true,
+			}
+		}
+
+		if err := stream.Send(resp); err != nil {
+			log.WithContext(ctx).Errorf("Failed to send reading: %v", err)
+			return err
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			log.WithContext(ctx).Info("Watch cancelled by client")
+			return ctx.Err()
+		}
+	}
+}
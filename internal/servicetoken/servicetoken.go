@@ -0,0 +1,237 @@
+package servicetoken
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/brochadoluis/temperature-exercise/internal/config"
+)
+
+// Names the services identify themselves with, used as token issuer and
+// audience.
+const (
+	API      = "api"
+	Scrapper = "scrapper"
+	Database = "database"
+	// Tempctl is the identity of the tempctl debugging CLI.
+	Tempctl = "tempctl"
+	// Loadgen is the identity of the load generator.
+	Loadgen = "loadgen"
+)
+
+const (
+	// metadataKey carries the token as a bearer token.
+	metadataKey  = "authorization"
+	bearerPrefix = "Bearer "
+
+	// leeway absorbs clock skew between the containers.
+	leeway = 5 * time.Second
+)
+
+// Signer issues tokens for the calls of one service.
+type Signer struct {
+	enabled bool
+	issuer  string
+	keyID   string
+	secret  []byte
+	ttl     time.Duration
+}
+
+func NewSigner(issuer string, cfg config.ServiceAuth) *Signer {
+	return &Signer{
+		enabled: cfg.Enabled,
+		issuer:  issuer,
+		keyID:   cfg.SigningKeyID,
+		secret:  []byte(cfg.Keys[cfg.SigningKeyID]),
+		ttl:     cfg.TokenTTL,
+	}
+}
+
+// Token returns a token for a call to the audience service.
+func (s *Signer) Token(audience string) (string, error) {
+	now := time.Now()
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+		Issuer:    s.issuer,
+		Audience:  jwt.ClaimStrings{audience},
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(s.ttl)),
+	})
+	token.Header["kid"] = s.keyID
+
+	signed, err := token.SignedString(s.secret)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to sign service token")
+	}
+	return signed, nil
+}
+
+func (s *Signer) outgoing(ctx context.Context, audience string) (context.Context, error) {
+	if !s.enabled {
+		return ctx, nil
+	}
+	token, err := s.Token(audience)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return metadata.AppendToOutgoingContext(ctx, metadataKey, bearerPrefix+token), nil
+}
+
+// UnaryClientInterceptor attaches a token for the audience service to every
+// unary call.
+func (s *Signer) UnaryClientInterceptor(audience string) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		ctx, err := s.outgoing(ctx, audience)
+		if err != nil {
+			return err
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// StreamClientInterceptor attaches a token for the audience service to every
+// stream.
+func (s *Signer) StreamClientInterceptor(audience string) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		ctx, err := s.outgoing(ctx, audience)
+		if err != nil {
+			return nil, err
+		}
+		return streamer(ctx, desc, cc, method, opts...)
+	}
+}
+
+// Policy lists, by full gRPC method name, the services allowed to call the
+// method. Methods listed as Public accept calls without a token but still
+// reject invalid ones. Every other method requires a valid token, from any
+// service unless the method is listed.
+type Policy map[string][]string
+
+// Public marks a method of a Policy anyone may call without a token.
+var Public = []string{}
+
+// services are the issuers keys not bound to an issuer sign for.
+var services = map[string]bool{API: true, Scrapper: true, Database: true}
+
+// Verifier checks the tokens received by one service.
+type Verifier struct {
+	enabled  bool
+	audience string
+	keys     map[string][]byte
+	issuers  map[string]string
+	policy   Policy
+	parser   *jwt.Parser
+}
+
+func NewVerifier(audience string, cfg config.ServiceAuth, policy Policy) *Verifier {
+	keys := make(map[string][]byte, len(cfg.Keys))
+	for id, secret := range cfg.Keys {
+		keys[id] = []byte(secret)
+	}
+
+	return &Verifier{
+		enabled:  cfg.Enabled,
+		audience: audience,
+		keys:     keys,
+		issuers:  cfg.KeyIssuers,
+		policy:   policy,
+		parser: jwt.NewParser(
+			jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+			jwt.WithAudience(audience),
+			jwt.WithExpirationRequired(),
+			jwt.WithIssuedAt(),
+			jwt.WithLeeway(leeway),
+		),
+	}
+}
+
+// Verify parses the token and returns the service that issued it. The issuer
+// must be the one the signing key is bound to, or one of the services for
+// keys not bound to any.
+func (v *Verifier) Verify(token string) (string, error) {
+	claims := &jwt.RegisteredClaims{}
+	var id string
+	_, err := v.parser.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		id, _ = t.Header["kid"].(string)
+		key, ok := v.keys[id]
+		if !ok {
+			return nil, errors.Errorf("unknown key %q", id)
+		}
+		return key, nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if claims.Issuer == "" {
+		return "", errors.New("token has no issuer")
+	}
+	if bound, ok := v.issuers[id]; (ok && bound != claims.Issuer) || (!ok && !services[claims.Issuer]) {
+		return "", errors.Errorf("key %q does not sign for %s", id, claims.Issuer)
+	}
+	return claims.Issuer, nil
+}
+
+func (v *Verifier) authorize(ctx context.Context, method string) error {
+	if !v.enabled {
+		return nil
+	}
+
+	var token string
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get(metadataKey); len(values) > 0 {
+		token = strings.TrimPrefix(values[0], bearerPrefix)
+	}
+
+	allowed, listed := v.policy[method]
+	public := listed && len(allowed) == 0
+	if token == "" {
+		if public {
+			return nil
+		}
+		return status.Error(codes.Unauthenticated, "service token required")
+	}
+
+	issuer, err := v.Verify(token)
+	if err != nil {
+		log.WithContext(ctx).Warnf("Rejected service token for %s: %v", method, err)
+		return status.Error(codes.Unauthenticated, "invalid service token")
+	}
+	if !listed || public {
+		return nil
+	}
+	for _, service := range allowed {
+		if service == issuer {
+			return nil
+		}
+	}
+	log.WithContext(ctx).Warnf("Service %s is not allowed to call %s", issuer, method)
+	return status.Errorf(codes.PermissionDenied, "%s is not allowed to call %s", issuer, method)
+}
+
+// UnaryServerInterceptor rejects unary calls that do not satisfy the policy.
+func (v *Verifier) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := v.authorize(ctx, info.FullMethod); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor rejects streams that do not satisfy the policy.
+func (v *Verifier) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := v.authorize(ss.Context(), info.FullMethod); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
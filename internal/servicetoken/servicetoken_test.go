@@ -0,0 +1,97 @@
+package servicetoken
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/brochadoluis/temperature-exercise/internal/config"
+)
+
+const (
+	restrictedMethod = "/temperature.Temperature/SaveReading"
+	tempctlMethod    = "/temperature.Temperature/ListReadings"
+	unlistedMethod   = "/temperature.Temperature/GetTemperature"
+	publicMethod     = "/grpc.health.v1.Health/Check"
+)
+
+// serviceAuth signs with the shared key k1, or the key bound to tempctl.
+func serviceAuth(keyID string, ttl time.Duration) config.ServiceAuth {
+	return config.ServiceAuth{
+		Enabled:      true,
+		SigningKeyID: keyID,
+		Keys:         map[string]string{"k1": "secret", "tempctl": "tempctl secret"},
+		KeyIssuers:   map[string]string{"tempctl": Tempctl},
+		TokenTTL:     ttl,
+	}
+}
+
+// tamper flips the first character of the signature of token.
+func tamper(token string) string {
+	i := strings.LastIndex(token, ".") + 1
+	flipped := "A"
+	if token[i] == 'A' {
+		flipped = "B"
+	}
+	return token[:i] + flipped + token[i+1:]
+}
+
+func TestVerifier(t *testing.T) {
+	policy := Policy{restrictedMethod: {Scrapper}, tempctlMethod: {Tempctl}, publicMethod: Public}
+	verifier := NewVerifier(Database, serviceAuth("k1", time.Minute), policy)
+
+	signed := func(keyID, issuer, audience string, ttl time.Duration) string {
+		signed, err := NewSigner(issuer, serviceAuth(keyID, ttl)).Token(audience)
+		if err != nil {
+			t.Fatalf("Failed to sign a token: %v", err)
+		}
+		return signed
+	}
+	token := func(issuer, audience string, ttl time.Duration) string {
+		return signed("k1", issuer, audience, ttl)
+	}
+
+	tests := []struct {
+		name   string
+		token  string
+		method string
+		want   codes.Code
+	}{
+		{"allowed", token(Scrapper, Database, time.Minute), restrictedMethod, codes.OK},
+		{"expired", token(Scrapper, Database, -time.Minute), restrictedMethod, codes.Unauthenticated},
+		{"wrong audience", token(Scrapper, API, time.Minute), restrictedMethod, codes.Unauthenticated},
+		{"tampered signature", tamper(token(Scrapper, Database, time.Minute)), restrictedMethod, codes.Unauthenticated},
+		{"method not allowed", token(API, Database, time.Minute), restrictedMethod, codes.PermissionDenied},
+		{"missing on restricted method", "", restrictedMethod, codes.Unauthenticated},
+		{"tempctl with its key", signed("tempctl", Tempctl, Database, time.Minute), tempctlMethod, codes.OK},
+		{"tempctl key posing as a service", signed("tempctl", Scrapper, Database, time.Minute), restrictedMethod, codes.Unauthenticated},
+		{"shared key posing as tempctl", token(Tempctl, Database, time.Minute), tempctlMethod, codes.Unauthenticated},
+		{"any service on unlisted method", token(API, Database, time.Minute), unlistedMethod, codes.OK},
+		{"missing on unlisted method", "", unlistedMethod, codes.Unauthenticated},
+		{"missing on public method", "", publicMethod, codes.OK},
+		{"expired on public method", token(API, Database, -time.Minute), publicMethod, codes.Unauthenticated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.token != "" {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(metadataKey, bearerPrefix+tt.token))
+			}
+			info := &grpc.UnaryServerInfo{FullMethod: tt.method}
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				return nil, nil
+			}
+
+			_, err := verifier.UnaryServerInterceptor()(ctx, nil, info, handler)
+			if got := status.Code(err); got != tt.want {
+				t.Errorf("Call answered %v, want %v: %v", got, tt.want, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package shutdown
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+// Context returns a context that is cancelled when the process receives
+// SIGINT or SIGTERM.
+func Context(parent context.Context) (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(parent, os.Interrupt, syscall.SIGTERM)
+}
+
+// GracefulStop stops the server from accepting new calls and waits for the
+// in-flight ones to finish. Calls still running after timeout are cancelled.
+// It reports whether the server drained before the timeout.
+func GracefulStop(server *grpc.Server, timeout time.Duration) bool {
+	stopped := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(stopped)
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-stopped:
+		return true
+	case <-timer.C:
+		server.Stop()
+		<-stopped
+		return false
+	}
+}
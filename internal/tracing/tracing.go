@@ -0,0 +1,115 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"os"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc/filters"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+
+	"github.com/brochadoluis/temperature-exercise/internal/config"
+)
+
+// Exporters supported by Setup.
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+)
+
+// Setup installs the global tracer provider and the W3C trace context
+// propagator for the service. The returned function flushes the pending spans
+// and must be called before the binary exits.
+func Setup(ctx context.Context, service string, cfg config.Tracing) (func(context.Context) error, error) {
+	// Propagate the trace context even when this service does not export, so
+	// traces are not cut in the middle of the pipeline
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch cfg.Exporter {
+	case ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case ExporterOTLP:
+		opts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(cfg.Endpoint)}
+		if cfg.Insecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+		exporter, err = otlptracegrpc.New(ctx, opts...)
+	default:
+		return nil, errors.Errorf("unknown tracing exporter %q", cfg.Exporter)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create trace exporter")
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(service),
+	))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create trace resource")
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// Start starts a span named name from the global tracer provider.
+func Start(ctx context.Context, name string) (context.Context, trace.Span) {
+	return otel.Tracer("github.com/brochadoluis/temperature-exercise").Start(ctx, name)
+}
+
+// Health checks run every few seconds and would drown the useful traces
+var skipHealthChecks = otelgrpc.WithInterceptorFilter(filters.Not(filters.HealthCheck()))
+
+// UnaryServerInterceptor starts a span for every incoming unary call,
+// continuing the trace found in the gRPC metadata.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return otelgrpc.UnaryServerInterceptor(skipHealthChecks)
+}
+
+// StreamServerInterceptor starts a span for every incoming stream.
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return otelgrpc.StreamServerInterceptor(skipHealthChecks)
+}
+
+// UnaryClientInterceptor starts a span for every outgoing unary call and
+// propagates the trace context in the gRPC metadata.
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return otelgrpc.UnaryClientInterceptor(skipHealthChecks)
+}
+
+// StreamClientInterceptor starts a span for every outgoing stream.
+func StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return otelgrpc.StreamClientInterceptor(skipHealthChecks)
+}
+
+// Transport wraps an HTTP transport to start a span for every outbound request
+// and propagate the trace context in its headers.
+func Transport(next http.RoundTripper) http.RoundTripper {
+	return otelhttp.NewTransport(next)
+}
@@ -0,0 +1,86 @@
+package units
+
+import (
+	"github.com/pkg/errors"
+)
+
+// Units is a system of units a temperature can be reported in. Readings are
+// always stored in Celsius and only converted on the way out.
+type Units string
+
+const (
+	Metric   Units = "metric"
+	Imperial Units = "imperial"
+	Kelvin   Units = "kelvin"
+)
+
+// Parse returns the Units named by value. An empty value means Metric.
+func Parse(value string) (Units, error) {
+	switch Units(value) {
+	case "":
+		return Metric, nil
+	case Metric, Imperial, Kelvin:
+		return Units(value), nil
+	default:
+		return "", errors.Errorf("unknown units %q, expected metric, imperial or kelvin", value)
+	}
+}
+
+// Symbol returns the symbol of the temperature unit.
+func (u Units) Symbol() string {
+	switch u {
+	case Imperial:
+		return "°F"
+	case Kelvin:
+		return "K"
+	default:
+		return "°C"
+	}
+}
+
+// FromCelsius converts a temperature in Celsius to u.
+func (u Units) FromCelsius(celsius float64) float64 {
+	switch u {
+	case Imperial:
+		return celsius*9/5 + 32
+	case Kelvin:
+		return celsius + 273.15
+	default:
+		return celsius
+	}
+}
+
+// ToCelsius converts a temperature in u to Celsius.
+func (u Units) ToCelsius(value float64) float64 {
+	switch u {
+	case Imperial:
+		return (value - 32) * 5 / 9
+	case Kelvin:
+		return value - 273.15
+	default:
+		return value
+	}
+}
+
+// Thresholds are the bounds outside of which a temperature raises an alert.
+type Thresholds struct {
+	Low  float64
+	High float64
+}
+
+// DefaultThresholds are the alert thresholds in Celsius.
+var DefaultThresholds = Thresholds{Low: 10, High: 40}
+
+// Alert reports whether the temperature, in the same unit as the thresholds,
+// is outside of them.
+func (t Thresholds) Alert(temperature float64) bool {
+	return temperature < t.Low || temperature > t.High
+}
+
+// In converts thresholds expressed in Celsius to u.
+func (t Thresholds) In(u Units) Thresholds {
+	return Thresholds{
+		Low:  u.FromCelsius(t.Low),
+		High: u.FromCelsius(t.High),
+	}
+}
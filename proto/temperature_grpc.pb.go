@@ -24,6 +24,20 @@ const _ = grpc.SupportPackageIsVersion7
 type TemperatureClient interface {
 	ListTemperature(ctx context.Context, in *ListTemperatureRequest, opts ...grpc.CallOption) (*ListTemperatureResponse, error)
 	SaveTemperature(ctx context.Context, in *SaveTemperatureRequest, opts ...grpc.CallOption) (*SaveTemperatureResponse, error)
+	SubscribeAlerts(ctx context.Context, opts ...grpc.CallOption) (Temperature_SubscribeAlertsClient, error)
+	WatchTemperature(ctx context.Context, in *WatchTemperatureRequest, opts ...grpc.CallOption) (Temperature_WatchTemperatureClient, error)
+	ListForecast(ctx context.Context, in *ListForecastRequest, opts ...grpc.CallOption) (*ListForecastResponse, error)
+	SaveForecast(ctx context.Context, in *SaveForecastRequest, opts ...grpc.CallOption) (*SaveForecastResponse, error)
+	CreateAPIKey(ctx context.Context, in *CreateAPIKeyRequest, opts ...grpc.CallOption) (*CreateAPIKeyResponse, error)
+	RevokeAPIKey(ctx context.Context, in *RevokeAPIKeyRequest, opts ...grpc.CallOption) (*RevokeAPIKeyResponse, error)
+	ValidateAPIKey(ctx context.Context, in *ValidateAPIKeyRequest, opts ...grpc.CallOption) (*APIKey, error)
+	ListReadings(ctx context.Context, in *ListReadingsRequest, opts ...grpc.CallOption) (*ListReadingsResponse, error)
+	ListActiveAlerts(ctx context.Context, in *ListActiveAlertsRequest, opts ...grpc.CallOption) (*ListActiveAlertsResponse, error)
+	AddToWatchlist(ctx context.Context, in *AddToWatchlistRequest, opts ...grpc.CallOption) (*WatchlistEntry, error)
+	RemoveFromWatchlist(ctx context.Context, in *RemoveFromWatchlistRequest, opts ...grpc.CallOption) (*RemoveFromWatchlistResponse, error)
+	ListWatchlist(ctx context.Context, in *ListWatchlistRequest, opts ...grpc.CallOption) (*ListWatchlistResponse, error)
+	SetFaults(ctx context.Context, in *SetFaultsRequest, opts ...grpc.CallOption) (*SetFaultsResponse, error)
+	ListFaults(ctx context.Context, in *ListFaultsRequest, opts ...grpc.CallOption) (*ListFaultsResponse, error)
 }
 
 type temperatureClient struct {
@@ -52,12 +66,197 @@ func (c *temperatureClient) SaveTemperature(ctx context.Context, in *SaveTempera
 	return out, nil
 }
 
+func (c *temperatureClient) SubscribeAlerts(ctx context.Context, opts ...grpc.CallOption) (Temperature_SubscribeAlertsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Temperature_ServiceDesc.Streams[0], "/temperature.Temperature/SubscribeAlerts", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &temperatureSubscribeAlertsClient{stream}
+	return x, nil
+}
+
+type Temperature_SubscribeAlertsClient interface {
+	Send(*AlertSubscriptionRequest) error
+	Recv() (*AlertEvent, error)
+	grpc.ClientStream
+}
+
+type temperatureSubscribeAlertsClient struct {
+	grpc.ClientStream
+}
+
+func (x *temperatureSubscribeAlertsClient) Send(m *AlertSubscriptionRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *temperatureSubscribeAlertsClient) Recv() (*AlertEvent, error) {
+	m := new(AlertEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *temperatureClient) WatchTemperature(ctx context.Context, in *WatchTemperatureRequest, opts ...grpc.CallOption) (Temperature_WatchTemperatureClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Temperature_ServiceDesc.Streams[1], "/temperature.Temperature/WatchTemperature", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &temperatureWatchTemperatureClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Temperature_WatchTemperatureClient interface {
+	Recv() (*ListTemperatureResponse, error)
+	grpc.ClientStream
+}
+
+type temperatureWatchTemperatureClient struct {
+	grpc.ClientStream
+}
+
+func (x *temperatureWatchTemperatureClient) Recv() (*ListTemperatureResponse, error) {
+	m := new(ListTemperatureResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *temperatureClient) ListForecast(ctx context.Context, in *ListForecastRequest, opts ...grpc.CallOption) (*ListForecastResponse, error) {
+	out := new(ListForecastResponse)
+	err := c.cc.Invoke(ctx, "/temperature.Temperature/ListForecast", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *temperatureClient) SaveForecast(ctx context.Context, in *SaveForecastRequest, opts ...grpc.CallOption) (*SaveForecastResponse, error) {
+	out := new(SaveForecastResponse)
+	err := c.cc.Invoke(ctx, "/temperature.Temperature/SaveForecast", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *temperatureClient) CreateAPIKey(ctx context.Context, in *CreateAPIKeyRequest, opts ...grpc.CallOption) (*CreateAPIKeyResponse, error) {
+	out := new(CreateAPIKeyResponse)
+	err := c.cc.Invoke(ctx, "/temperature.Temperature/CreateAPIKey", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *temperatureClient) RevokeAPIKey(ctx context.Context, in *RevokeAPIKeyRequest, opts ...grpc.CallOption) (*RevokeAPIKeyResponse, error) {
+	out := new(RevokeAPIKeyResponse)
+	err := c.cc.Invoke(ctx, "/temperature.Temperature/RevokeAPIKey", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *temperatureClient) ValidateAPIKey(ctx context.Context, in *ValidateAPIKeyRequest, opts ...grpc.CallOption) (*APIKey, error) {
+	out := new(APIKey)
+	err := c.cc.Invoke(ctx, "/temperature.Temperature/ValidateAPIKey", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *temperatureClient) ListReadings(ctx context.Context, in *ListReadingsRequest, opts ...grpc.CallOption) (*ListReadingsResponse, error) {
+	out := new(ListReadingsResponse)
+	err := c.cc.Invoke(ctx, "/temperature.Temperature/ListReadings", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *temperatureClient) ListActiveAlerts(ctx context.Context, in *ListActiveAlertsRequest, opts ...grpc.CallOption) (*ListActiveAlertsResponse, error) {
+	out := new(ListActiveAlertsResponse)
+	err := c.cc.Invoke(ctx, "/temperature.Temperature/ListActiveAlerts", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *temperatureClient) AddToWatchlist(ctx context.Context, in *AddToWatchlistRequest, opts ...grpc.CallOption) (*WatchlistEntry, error) {
+	out := new(WatchlistEntry)
+	err := c.cc.Invoke(ctx, "/temperature.Temperature/AddToWatchlist", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *temperatureClient) RemoveFromWatchlist(ctx context.Context, in *RemoveFromWatchlistRequest, opts ...grpc.CallOption) (*RemoveFromWatchlistResponse, error) {
+	out := new(RemoveFromWatchlistResponse)
+	err := c.cc.Invoke(ctx, "/temperature.Temperature/RemoveFromWatchlist", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *temperatureClient) ListWatchlist(ctx context.Context, in *ListWatchlistRequest, opts ...grpc.CallOption) (*ListWatchlistResponse, error) {
+	out := new(ListWatchlistResponse)
+	err := c.cc.Invoke(ctx, "/temperature.Temperature/ListWatchlist", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *temperatureClient) SetFaults(ctx context.Context, in *SetFaultsRequest, opts ...grpc.CallOption) (*SetFaultsResponse, error) {
+	out := new(SetFaultsResponse)
+	err := c.cc.Invoke(ctx, "/temperature.Temperature/SetFaults", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *temperatureClient) ListFaults(ctx context.Context, in *ListFaultsRequest, opts ...grpc.CallOption) (*ListFaultsResponse, error) {
+	out := new(ListFaultsResponse)
+	err := c.cc.Invoke(ctx, "/temperature.Temperature/ListFaults", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // TemperatureServer is the server API for Temperature service.
 // All implementations must embed UnimplementedTemperatureServer
 // for forward compatibility
 type TemperatureServer interface {
 	ListTemperature(context.Context, *ListTemperatureRequest) (*ListTemperatureResponse, error)
 	SaveTemperature(context.Context, *SaveTemperatureRequest) (*SaveTemperatureResponse, error)
+	SubscribeAlerts(Temperature_SubscribeAlertsServer) error
+	WatchTemperature(*WatchTemperatureRequest, Temperature_WatchTemperatureServer) error
+	ListForecast(context.Context, *ListForecastRequest) (*ListForecastResponse, error)
+	SaveForecast(context.Context, *SaveForecastRequest) (*SaveForecastResponse, error)
+	CreateAPIKey(context.Context, *CreateAPIKeyRequest) (*CreateAPIKeyResponse, error)
+	RevokeAPIKey(context.Context, *RevokeAPIKeyRequest) (*RevokeAPIKeyResponse, error)
+	ValidateAPIKey(context.Context, *ValidateAPIKeyRequest) (*APIKey, error)
+	ListReadings(context.Context, *ListReadingsRequest) (*ListReadingsResponse, error)
+	ListActiveAlerts(context.Context, *ListActiveAlertsRequest) (*ListActiveAlertsResponse, error)
+	AddToWatchlist(context.Context, *AddToWatchlistRequest) (*WatchlistEntry, error)
+	RemoveFromWatchlist(context.Context, *RemoveFromWatchlistRequest) (*RemoveFromWatchlistResponse, error)
+	ListWatchlist(context.Context, *ListWatchlistRequest) (*ListWatchlistResponse, error)
+	SetFaults(context.Context, *SetFaultsRequest) (*SetFaultsResponse, error)
+	ListFaults(context.Context, *ListFaultsRequest) (*ListFaultsResponse, error)
 	mustEmbedUnimplementedTemperatureServer()
 }
 
@@ -71,6 +270,48 @@ func (UnimplementedTemperatureServer) ListTemperature(context.Context, *ListTemp
 func (UnimplementedTemperatureServer) SaveTemperature(context.Context, *SaveTemperatureRequest) (*SaveTemperatureResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method SaveTemperature not implemented")
 }
+func (UnimplementedTemperatureServer) SubscribeAlerts(Temperature_SubscribeAlertsServer) error {
+	return status.Errorf(codes.Unimplemented, "method SubscribeAlerts not implemented")
+}
+func (UnimplementedTemperatureServer) WatchTemperature(*WatchTemperatureRequest, Temperature_WatchTemperatureServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchTemperature not implemented")
+}
+func (UnimplementedTemperatureServer) ListForecast(context.Context, *ListForecastRequest) (*ListForecastResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListForecast not implemented")
+}
+func (UnimplementedTemperatureServer) SaveForecast(context.Context, *SaveForecastRequest) (*SaveForecastResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SaveForecast not implemented")
+}
+func (UnimplementedTemperatureServer) CreateAPIKey(context.Context, *CreateAPIKeyRequest) (*CreateAPIKeyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateAPIKey not implemented")
+}
+func (UnimplementedTemperatureServer) RevokeAPIKey(context.Context, *RevokeAPIKeyRequest) (*RevokeAPIKeyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RevokeAPIKey not implemented")
+}
+func (UnimplementedTemperatureServer) ValidateAPIKey(context.Context, *ValidateAPIKeyRequest) (*APIKey, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ValidateAPIKey not implemented")
+}
+func (UnimplementedTemperatureServer) ListReadings(context.Context, *ListReadingsRequest) (*ListReadingsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListReadings not implemented")
+}
+func (UnimplementedTemperatureServer) ListActiveAlerts(context.Context, *ListActiveAlertsRequest) (*ListActiveAlertsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListActiveAlerts not implemented")
+}
+func (UnimplementedTemperatureServer) AddToWatchlist(context.Context, *AddToWatchlistRequest) (*WatchlistEntry, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddToWatchlist not implemented")
+}
+func (UnimplementedTemperatureServer) RemoveFromWatchlist(context.Context, *RemoveFromWatchlistRequest) (*RemoveFromWatchlistResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemoveFromWatchlist not implemented")
+}
+func (UnimplementedTemperatureServer) ListWatchlist(context.Context, *ListWatchlistRequest) (*ListWatchlistResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListWatchlist not implemented")
+}
+func (UnimplementedTemperatureServer) SetFaults(context.Context, *SetFaultsRequest) (*SetFaultsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetFaults not implemented")
+}
+func (UnimplementedTemperatureServer) ListFaults(context.Context, *ListFaultsRequest) (*ListFaultsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListFaults not implemented")
+}
 func (UnimplementedTemperatureServer) mustEmbedUnimplementedTemperatureServer() {}
 
 // UnsafeTemperatureServer may be embedded to opt out of forward compatibility for this service.
@@ -120,6 +361,269 @@ func _Temperature_SaveTemperature_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Temperature_SubscribeAlerts_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(TemperatureServer).SubscribeAlerts(&temperatureSubscribeAlertsServer{stream})
+}
+
+type Temperature_SubscribeAlertsServer interface {
+	Send(*AlertEvent) error
+	Recv() (*AlertSubscriptionRequest, error)
+	grpc.ServerStream
+}
+
+type temperatureSubscribeAlertsServer struct {
+	grpc.ServerStream
+}
+
+func (x *temperatureSubscribeAlertsServer) Send(m *AlertEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *temperatureSubscribeAlertsServer) Recv() (*AlertSubscriptionRequest, error) {
+	m := new(AlertSubscriptionRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _Temperature_WatchTemperature_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchTemperatureRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(TemperatureServer).WatchTemperature(m, &temperatureWatchTemperatureServer{stream})
+}
+
+type Temperature_WatchTemperatureServer interface {
+	Send(*ListTemperatureResponse) error
+	grpc.ServerStream
+}
+
+type temperatureWatchTemperatureServer struct {
+	grpc.ServerStream
+}
+
+func (x *temperatureWatchTemperatureServer) Send(m *ListTemperatureResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Temperature_ListForecast_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListForecastRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TemperatureServer).ListForecast(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/temperature.Temperature/ListForecast",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TemperatureServer).ListForecast(ctx, req.(*ListForecastRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Temperature_SaveForecast_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SaveForecastRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TemperatureServer).SaveForecast(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/temperature.Temperature/SaveForecast",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TemperatureServer).SaveForecast(ctx, req.(*SaveForecastRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Temperature_CreateAPIKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateAPIKeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TemperatureServer).CreateAPIKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/temperature.Temperature/CreateAPIKey",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TemperatureServer).CreateAPIKey(ctx, req.(*CreateAPIKeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Temperature_RevokeAPIKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RevokeAPIKeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TemperatureServer).RevokeAPIKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/temperature.Temperature/RevokeAPIKey",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TemperatureServer).RevokeAPIKey(ctx, req.(*RevokeAPIKeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Temperature_ValidateAPIKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ValidateAPIKeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TemperatureServer).ValidateAPIKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/temperature.Temperature/ValidateAPIKey",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TemperatureServer).ValidateAPIKey(ctx, req.(*ValidateAPIKeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Temperature_ListReadings_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListReadingsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TemperatureServer).ListReadings(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/temperature.Temperature/ListReadings",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TemperatureServer).ListReadings(ctx, req.(*ListReadingsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Temperature_ListActiveAlerts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListActiveAlertsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TemperatureServer).ListActiveAlerts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/temperature.Temperature/ListActiveAlerts",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TemperatureServer).ListActiveAlerts(ctx, req.(*ListActiveAlertsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Temperature_AddToWatchlist_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddToWatchlistRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TemperatureServer).AddToWatchlist(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/temperature.Temperature/AddToWatchlist",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TemperatureServer).AddToWatchlist(ctx, req.(*AddToWatchlistRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Temperature_RemoveFromWatchlist_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RemoveFromWatchlistRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TemperatureServer).RemoveFromWatchlist(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/temperature.Temperature/RemoveFromWatchlist",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TemperatureServer).RemoveFromWatchlist(ctx, req.(*RemoveFromWatchlistRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Temperature_ListWatchlist_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListWatchlistRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TemperatureServer).ListWatchlist(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/temperature.Temperature/ListWatchlist",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TemperatureServer).ListWatchlist(ctx, req.(*ListWatchlistRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Temperature_SetFaults_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetFaultsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TemperatureServer).SetFaults(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/temperature.Temperature/SetFaults",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TemperatureServer).SetFaults(ctx, req.(*SetFaultsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Temperature_ListFaults_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListFaultsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TemperatureServer).ListFaults(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/temperature.Temperature/ListFaults",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TemperatureServer).ListFaults(ctx, req.(*ListFaultsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // Temperature_ServiceDesc is the grpc.ServiceDesc for Temperature service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -135,7 +639,67 @@ var Temperature_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "SaveTemperature",
 			Handler:    _Temperature_SaveTemperature_Handler,
 		},
+		{
+			MethodName: "ListForecast",
+			Handler:    _Temperature_ListForecast_Handler,
+		},
+		{
+			MethodName: "SaveForecast",
+			Handler:    _Temperature_SaveForecast_Handler,
+		},
+		{
+			MethodName: "CreateAPIKey",
+			Handler:    _Temperature_CreateAPIKey_Handler,
+		},
+		{
+			MethodName: "RevokeAPIKey",
+			Handler:    _Temperature_RevokeAPIKey_Handler,
+		},
+		{
+			MethodName: "ValidateAPIKey",
+			Handler:    _Temperature_ValidateAPIKey_Handler,
+		},
+		{
+			MethodName: "ListReadings",
+			Handler:    _Temperature_ListReadings_Handler,
+		},
+		{
+			MethodName: "ListActiveAlerts",
+			Handler:    _Temperature_ListActiveAlerts_Handler,
+		},
+		{
+			MethodName: "AddToWatchlist",
+			Handler:    _Temperature_AddToWatchlist_Handler,
+		},
+		{
+			MethodName: "RemoveFromWatchlist",
+			Handler:    _Temperature_RemoveFromWatchlist_Handler,
+		},
+		{
+			MethodName: "ListWatchlist",
+			Handler:    _Temperature_ListWatchlist_Handler,
+		},
+		{
+			MethodName: "SetFaults",
+			Handler:    _Temperature_SetFaults_Handler,
+		},
+		{
+			MethodName: "ListFaults",
+			Handler:    _Temperature_ListFaults_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "SubscribeAlerts",
+			Handler:       _Temperature_SubscribeAlerts_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "WatchTemperature",
+			Handler:       _Temperature_WatchTemperature_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "temperature.proto",
 }